@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pingdomProbesRefreshInterval is how often the IP addresses of the Pingdom probes are fetched.
+const pingdomProbesRefreshInterval = 24 * time.Hour
+
+// pingdomProbesCacheTTL is how long the node keeps the probe addresses read from the KV store.
+const pingdomProbesCacheTTL = 10 * time.Minute
+
+// pingdomProbesRetryInterval is how long the failure to get the probe addresses is cached.
+const pingdomProbesRetryInterval = 5 * time.Minute
+
+// parseCIDRs parses the comma-separated list of CIDRs. Bare IP addresses are accepted as single hosts.
+func parseCIDRs(list string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for item := range splitList(list) {
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IP address %q", item)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", item)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSourceRestrictions tells whether the calls of the hook are limited to the allowed source addresses.
+func (ac *pingdomHookConfig) hasSourceRestrictions() bool {
+	return ac.AllowedCIDRs != "" || ac.AllowPingdomProbes
+}
+
+// clientIP returns the address the webhook call came from. X-Forwarded-For is only honoured when
+// the call comes through one of the TrustedProxies: the rightmost address which is not a trusted
+// proxy is the client, as the addresses on the left can be forged by the caller.
+func (ac *pingdomHookConfig) clientIP(r *http.Request) (netip.Addr, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid remote address %q", r.RemoteAddr)
+	}
+	addr = addr.Unmap()
+
+	trustedProxies, err := parseCIDRs(ac.TrustedProxies)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if !containsAddr(trustedProxies, addr) {
+		return addr, nil
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		item := strings.TrimSpace(forwarded[i])
+		if item == "" {
+			continue
+		}
+		forwardedAddr, err := netip.ParseAddr(item)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("invalid X-Forwarded-For address %q", item)
+		}
+		addr = forwardedAddr.Unmap()
+		if !containsAddr(trustedProxies, addr) {
+			break
+		}
+	}
+	return addr, nil
+}
+
+// isSourceAllowed checks the address of the webhook call against the AllowedCIDRs and, if enabled,
+// the Pingdom probes. It returns the client address for logging.
+func (p *Plugin) isSourceAllowed(config pingdomHookConfig, r *http.Request) (netip.Addr, bool) {
+	if !config.hasSourceRestrictions() {
+		return netip.Addr{}, true
+	}
+
+	addr, err := config.clientIP(r)
+	if err != nil {
+		p.API.LogWarn("Failed to determine the client address of the webhook call", "hook_id", config.ID, "err", err.Error())
+		return addr, false
+	}
+
+	allowed, err := parseCIDRs(config.AllowedCIDRs)
+	if err != nil {
+		p.API.LogWarn("Invalid Allowed CIDRs", "hook_id", config.ID, "err", err.Error())
+	}
+	if containsAddr(allowed, addr) {
+		return addr, true
+	}
+
+	if config.AllowPingdomProbes {
+		probes, err := p.getPingdomProbes(config)
+		if err != nil {
+			p.API.LogWarn("Failed to get the Pingdom probes", "hook_id", config.ID, "err", err.Error())
+		}
+		if probes[addr] {
+			return addr, true
+		}
+	}
+
+	return addr, false
+}
+
+// probesCache keeps the probe addresses of the hooks read from the KV store. The failures to get them
+// are cached as well, so the webhook calls from the unknown addresses do not hammer the KV store and
+// the Pingdom API. The lock is never held across the I/O.
+type probesCache struct {
+	lock    sync.Mutex
+	entries map[string]probesCacheEntry
+	// fetching are the hooks whose probes are being fetched from the Pingdom API.
+	fetching map[string]bool
+}
+
+type probesCacheEntry struct {
+	addrs     map[netip.Addr]bool
+	expiresAt time.Time
+}
+
+func (c *probesCache) get(hookID string, now time.Time) (map[netip.Addr]bool, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[hookID]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.addrs, true
+}
+
+func (c *probesCache) set(hookID string, addrs map[netip.Addr]bool, expiresAt time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]probesCacheEntry)
+	}
+	c.entries[hookID] = probesCacheEntry{addrs: addrs, expiresAt: expiresAt}
+}
+
+func (c *probesCache) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = nil
+}
+
+// startFetch tells if the probes of the hook should be fetched, i.e. they are not being fetched already.
+func (c *probesCache) startFetch(hookID string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.fetching[hookID] {
+		return false
+	}
+	if c.fetching == nil {
+		c.fetching = make(map[string]bool)
+	}
+	c.fetching[hookID] = true
+	return true
+}
+
+func (c *probesCache) finishFetch(hookID string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.fetching, hookID)
+}
+
+// pingdomProbes is kept in the KV store per hook, as the probes are fetched with the API token of the hook.
+type pingdomProbes struct {
+	IPs       []string `json:"ips"`
+	FetchedAt int64    `json:"fetched_at"`
+}
+
+func pingdomProbesKey(hookID string) string {
+	return fmt.Sprintf("pingdom_probes_%s", hookID)
+}
+
+func (s pingdomProbes) addrs() map[netip.Addr]bool {
+	addrs := make(map[netip.Addr]bool, len(s.IPs))
+	for _, ip := range s.IPs {
+		if addr, err := netip.ParseAddr(ip); err == nil {
+			addrs[addr.Unmap()] = true
+		}
+	}
+	return addrs
+}
+
+// getPingdomProbes returns the addresses of the Pingdom probes for the hook. The probes are refreshed
+// by the cluster job, the hook which has none stored yet (e.g. the option has just been enabled) gets
+// them fetched in the background. The calls are rejected meanwhile, Pingdom retries them.
+func (p *Plugin) getPingdomProbes(config pingdomHookConfig) (map[netip.Addr]bool, error) {
+	now := time.Now()
+	if addrs, ok := p.probes.get(config.ID, now); ok {
+		return addrs, nil
+	}
+
+	var stored pingdomProbes
+	if err := p.client.KV.Get(pingdomProbesKey(config.ID), &stored); err != nil {
+		p.probes.set(config.ID, nil, now.Add(pingdomProbesRetryInterval))
+		return nil, fmt.Errorf("failed to get the Pingdom probes: %w", err)
+	}
+	if len(stored.IPs) == 0 {
+		p.probes.set(config.ID, nil, now.Add(pingdomProbesRetryInterval))
+		p.fetchPingdomProbesAsync(config)
+		return nil, errors.New("the Pingdom probes are not fetched yet")
+	}
+
+	addrs := stored.addrs()
+	p.probes.set(config.ID, addrs, now.Add(pingdomProbesCacheTTL))
+	return addrs, nil
+}
+
+// fetchPingdomProbesAsync fetches the probes of the hook in the background unless they are being
+// fetched already. The failure stays cached until the retry interval passes.
+func (p *Plugin) fetchPingdomProbesAsync(config pingdomHookConfig) {
+	if !p.probes.startFetch(config.ID) {
+		return
+	}
+	go func() {
+		defer p.probes.finishFetch(config.ID)
+
+		stored, err := p.fetchPingdomProbes(config)
+		if err != nil {
+			p.API.LogWarn("Failed to fetch the Pingdom probes", "hook_id", config.ID, "err", err.Error())
+			return
+		}
+		p.probes.set(config.ID, stored.addrs(), time.Now().Add(pingdomProbesCacheTTL))
+	}()
+}
+
+// fetchPingdomProbes fetches the probe addresses from the Pingdom API and stores them.
+func (p *Plugin) fetchPingdomProbes(config pingdomHookConfig) (pingdomProbes, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	probes, err := p.newPingdomClient(config.Token).GetProbes(ctx)
+	if err != nil {
+		return pingdomProbes{}, err
+	}
+
+	stored := pingdomProbes{FetchedAt: time.Now().UnixMilli()}
+	for _, probe := range probes {
+		for _, ip := range []string{probe.IP, probe.IPV6} {
+			if ip != "" {
+				stored.IPs = append(stored.IPs, ip)
+			}
+		}
+	}
+	if len(stored.IPs) == 0 {
+		return stored, fmt.Errorf("the Pingdom API returned no probes")
+	}
+
+	if _, err := p.client.KV.Set(pingdomProbesKey(config.ID), stored); err != nil {
+		return stored, fmt.Errorf("failed to store the Pingdom probes: %w", err)
+	}
+	return stored, nil
+}
+
+// refreshPingdomProbes is run by the cluster job to keep the probe addresses of the hooks up to date.
+func (p *Plugin) refreshPingdomProbes() {
+	for _, config := range p.getConfiguration().PingdomHooksConfigs {
+		if !config.AllowPingdomProbes || config.Token == "" {
+			continue
+		}
+
+		stored, err := p.fetchPingdomProbes(config)
+		if err != nil {
+			p.API.LogWarn("Failed to refresh the Pingdom probes", "hook_id", config.ID, "err", err.Error())
+			continue
+		}
+		p.API.LogDebug("Refreshed the Pingdom probes", "hook_id", config.ID, "count", len(stored.IPs))
+	}
+
+	p.probes.reset()
+}
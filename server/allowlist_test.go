@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		list        string
+		expected    int
+		expectedErr bool
+	}{
+		"empty":         {list: "", expected: 0},
+		"CIDRs":         {list: "10.0.0.0/8, 2001:db8::/32", expected: 2},
+		"bare address":  {list: "192.0.2.1", expected: 1},
+		"invalid CIDR":  {list: "10.0.0.0/33", expectedErr: true},
+		"invalid entry": {list: "10.0.0.0/8,example.com", expectedErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			prefixes, err := parseCIDRs(tc.list)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(prefixes) != tc.expected {
+				t.Errorf("expected %d prefixes, got %d", tc.expected, len(prefixes))
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config     pingdomHookConfig
+		remoteAddr string
+		forwarded  string
+		expected   string
+	}{
+		"remote address": {
+			remoteAddr: "192.0.2.1:1234",
+			expected:   "192.0.2.1",
+		},
+		"untrusted proxy is ignored": {
+			remoteAddr: "192.0.2.1:1234",
+			forwarded:  "198.51.100.1",
+			expected:   "192.0.2.1",
+		},
+		"trusted proxy": {
+			config:     pingdomHookConfig{TrustedProxies: "192.0.2.0/24"},
+			remoteAddr: "192.0.2.1:1234",
+			forwarded:  "198.51.100.1",
+			expected:   "198.51.100.1",
+		},
+		"forged address on the left": {
+			config:     pingdomHookConfig{TrustedProxies: "192.0.2.0/24"},
+			remoteAddr: "192.0.2.1:1234",
+			forwarded:  "203.0.113.1, 198.51.100.1",
+			expected:   "198.51.100.1",
+		},
+		"chain of trusted proxies": {
+			config:     pingdomHookConfig{TrustedProxies: "192.0.2.0/24"},
+			remoteAddr: "192.0.2.1:1234",
+			forwarded:  "198.51.100.1, 192.0.2.2",
+			expected:   "198.51.100.1",
+		},
+		"IPv4-mapped IPv6": {
+			remoteAddr: "[::ffff:192.0.2.1]:1234",
+			expected:   "192.0.2.1",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/webhook", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+
+			addr, err := tc.config.clientIP(r)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if addr.String() != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, addr)
+			}
+		})
+	}
+}
+
+func TestGetPingdomProbes(t *testing.T) {
+	api := newFakeAPI()
+	p := newTestPlugin(api, &configuration{})
+	if _, err := p.client.KV.Set(pingdomProbesKey("0"), pingdomProbes{IPs: []string{"203.0.113.1", "2001:db8::1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	probes, err := p.getPingdomProbes(pingdomHookConfig{ID: "0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !probes[netip.MustParseAddr("203.0.113.1")] || !probes[netip.MustParseAddr("2001:db8::1")] || len(probes) != 2 {
+		t.Errorf("unexpected probes: %v", probes)
+	}
+
+	// The probes of the hook "1" are being fetched, the calls do not wait for them.
+	p.probes.startFetch("1")
+	if _, err = p.getPingdomProbes(pingdomHookConfig{ID: "1"}); err == nil {
+		t.Error("expected the error while the probes are not fetched yet")
+	}
+	if _, err := p.client.KV.Set(pingdomProbesKey("1"), pingdomProbes{IPs: []string{"203.0.113.1"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if probes, err = p.getPingdomProbes(pingdomHookConfig{ID: "1"}); err != nil || len(probes) != 0 {
+		t.Errorf("expected the failure to stay cached, got %v, %v", probes, err)
+	}
+	if p.probes.startFetch("1") {
+		t.Error("expected the probes not to be fetched twice at once")
+	}
+	p.probes.finishFetch("1")
+
+	p.probes.set("1", nil, time.Now().Add(-time.Second))
+	if probes, err = p.getPingdomProbes(pingdomHookConfig{ID: "1"}); err != nil || len(probes) != 1 {
+		t.Errorf("expected the stored probes after the retry interval, got %v, %v", probes, err)
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+)
+
+// serveAPI handles the requests of the logged-in Mattermost users (the webapp).
+func (p *Plugin) serveAPI(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/api/v1/checks/state" && r.Method == http.MethodGet:
+		p.handleGetCheckState(w, r, userID)
+	case r.URL.Path == "/api/v1/alerts/acknowledge" && r.Method == http.MethodPost:
+		p.handleAcknowledgeAlert(w, r, userID)
+	case r.URL.Path == "/api/v1/hooks/seeds" && r.Method == http.MethodGet:
+		p.handleGetSeedUsage(w, r, userID)
+	case r.URL.Path == "/api/v1/hooks/stats" && r.Method == http.MethodGet:
+		p.handleGetHookStats(w, r, userID)
+	case r.URL.Path == "/api/v1/hooks/test" && r.Method == http.MethodPost:
+		p.handleSendTestAlert(w, r, userID)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleGetCheckState returns the latest known state of the check, so the old alerts can show
+// whether the check is still down.
+func (p *Plugin) handleGetCheckState(w http.ResponseWriter, r *http.Request, userID string) {
+	hookID := r.URL.Query().Get("hook_id")
+	checkID, err := strconv.ParseUint(r.URL.Query().Get("check_id"), 10, 64)
+	if hookID == "" || err != nil {
+		http.Error(w, "Invalid hook_id or check_id", http.StatusBadRequest)
+		return
+	}
+
+	config, ok := p.getConfiguration().PingdomHooksConfigs[hookID]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	channelID, err := p.lookupHookChannelID(config)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if _, appErr := p.API.GetChannelMember(channelID, userID); appErr != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	state, err := p.getCheckState(hookID, checkID)
+	if err != nil {
+		p.API.LogError("failed to get the check state", "hook_id", hookID, "check_id", checkID, "err", err.Error())
+		http.Error(w, "Failed to get the check state", http.StatusInternalServerError)
+		return
+	}
+	if state == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	writeJSON(w, state)
+}
+
+type acknowledgeRequest struct {
+	PostID string `json:"post_id"`
+}
+
+// handleAcknowledgeAlert marks the alert post as acknowledged by the user.
+func (p *Plugin) handleAcknowledgeAlert(w http.ResponseWriter, r *http.Request, userID string) {
+	var req acknowledgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PostID == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	post, appErr := p.API.GetPost(req.PostID)
+	if appErr != nil || post.Type != alertPostType {
+		http.NotFound(w, r)
+		return
+	}
+	if _, appErr = p.API.GetChannelMember(post.ChannelId, userID); appErr != nil {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if post.GetProp(propAcknowledgedBy) == nil {
+		post.AddProp(propAcknowledgedBy, userID)
+		post.AddProp(propAcknowledgedAt, time.Now().Unix())
+		if post, appErr = p.API.UpdatePost(post); appErr != nil {
+			p.API.LogError("failed to acknowledge the alert", "post_id", req.PostID, "err", appErr.Error())
+			http.Error(w, "Failed to acknowledge the alert", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeJSON(w, post.GetProps())
+}
+
+// seedUsageResponse tells the admin when the seeds of the hook were used last time.
+type seedUsageResponse struct {
+	SeedHint   string `json:"seed_hint"`
+	Current    bool   `json:"current"`
+	ExpiresAt  int64  `json:"expires_at,omitempty"`
+	LastUsedAt int64  `json:"last_used_at,omitempty"`
+}
+
+// handleGetSeedUsage returns the current and the retired seeds of the hook (hinted only) with the
+// time they were used last time.
+func (p *Plugin) handleGetSeedUsage(w http.ResponseWriter, r *http.Request, userID string) {
+	if !p.API.HasPermissionTo(userID, model.PermissionManageSystem) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	config, ok := p.getConfiguration().PingdomHooksConfigs[r.URL.Query().Get("hook_id")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	response := make([]seedUsageResponse, 0, len(config.RetiredSeeds)+1)
+	addSeed := func(seed string, current bool, expiresAt int64) {
+		if seed == "" {
+			return
+		}
+		usage, err := p.getSeedUsage(config.ID, seed)
+		if err != nil {
+			p.API.LogWarn("failed to get the seed usage", "hook_id", config.ID, "err", err.Error())
+		}
+		response = append(response, seedUsageResponse{
+			SeedHint:   seedHint(seed),
+			Current:    current,
+			ExpiresAt:  expiresAt,
+			LastUsedAt: usage.LastUsedAt,
+		})
+	}
+
+	addSeed(config.Seed, true, 0)
+	for _, rs := range config.RetiredSeeds {
+		addSeed(rs.Seed, false, rs.ExpiresAt)
+	}
+
+	writeJSON(w, response)
+}
+
+// handleGetHookStats returns the delivery stats of the hook.
+func (p *Plugin) handleGetHookStats(w http.ResponseWriter, r *http.Request, userID string) {
+	if !p.API.HasPermissionTo(userID, model.PermissionManageSystem) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	hookID := r.URL.Query().Get("hook_id")
+	if _, ok := p.getConfiguration().PingdomHooksConfigs[hookID]; !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	p.flushHookStats()
+	stats, err := p.getHookStats(hookID)
+	if err != nil {
+		p.API.LogError("failed to get the hook stats", "hook_id", hookID, "err", err.Error())
+		http.Error(w, "Failed to get the hook stats", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, stats)
+}
+
+type testAlertRequest struct {
+	HookID string `json:"hook_id"`
+	State  string `json:"state"`
+	Type   string `json:"type"`
+}
+
+// testAlertResponse tells the admin how the webhook responded to the test alert.
+type testAlertResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// handleSendTestAlert sends the test alert through the hook, the same way `/pingdom test` does.
+func (p *Plugin) handleSendTestAlert(w http.ResponseWriter, r *http.Request, userID string) {
+	if !p.API.HasPermissionTo(userID, model.PermissionManageSystem) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var req testAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.HookID == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.State == "" {
+		req.State = "DOWN"
+	}
+	if req.Type == "" {
+		req.Type = pingdom.CheckTypeHTTP
+	}
+
+	config, ok := p.getConfiguration().PingdomHooksConfigs[req.HookID]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	sentBy := userID
+	if user, appErr := p.API.GetUser(userID); appErr == nil {
+		sentBy = "@" + user.Username
+	}
+	code, err := p.sendTestAlert(config, req.Type, req.State, sentBy)
+	if err != nil {
+		// The alert which could not be built never reached the webhook.
+		if code == 0 {
+			code = http.StatusBadRequest
+		}
+		writeJSONStatus(w, code, testAlertResponse{Status: code, Message: err.Error()})
+		return
+	}
+	writeJSON(w, testAlertResponse{Status: code, Message: "The test alert had been accepted"})
+}
+
+// writeJSONStatus responds with the JSON body and the status other than 200.
+func writeJSONStatus(w http.ResponseWriter, code int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(append(body, '\n'))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+func TestHandleGetCheckState(t *testing.T) {
+	api := newFakeAPI()
+	api.addMember("channel-0", "member")
+	p := newTestPlugin(api, &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{
+		"0": {ID: "0", Team: "team", Channel: "alerts", Seed: "seed-0"},
+	}})
+	p.channels.set("0", "channel-0", time.Now())
+
+	message := uptime.Alert{CheckID: 1, CheckName: "web", CurrentState: "DOWN", PreviousState: "UP", StateChangedTimestamp: 1700000000}
+	if err := p.saveCheckState("0", message, "post-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		userID   string
+		url      string
+		expected int
+	}{
+		"anonymous": {
+			url:      "/api/v1/checks/state?hook_id=0&check_id=1",
+			expected: http.StatusUnauthorized,
+		},
+		"invalid check ID": {
+			userID:   "member",
+			url:      "/api/v1/checks/state?hook_id=0&check_id=web",
+			expected: http.StatusBadRequest,
+		},
+		"unknown hook": {
+			userID:   "member",
+			url:      "/api/v1/checks/state?hook_id=1&check_id=1",
+			expected: http.StatusNotFound,
+		},
+		"not a member of the channel": {
+			userID:   "stranger",
+			url:      "/api/v1/checks/state?hook_id=0&check_id=1",
+			expected: http.StatusForbidden,
+		},
+		"unknown check": {
+			userID:   "member",
+			url:      "/api/v1/checks/state?hook_id=0&check_id=2",
+			expected: http.StatusNotFound,
+		},
+		"member": {
+			userID:   "member",
+			url:      "/api/v1/checks/state?hook_id=0&check_id=1",
+			expected: http.StatusOK,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			if tc.userID != "" {
+				r.Header.Set("Mattermost-User-ID", tc.userID)
+			}
+
+			p.ServeHTTP(nil, w, r)
+
+			if w.Code != tc.expected {
+				t.Fatalf("expected status %d, got %d", tc.expected, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var state checkState
+			if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if state.CheckID != 1 || state.State != "DOWN" || state.PostID != "post-1" {
+				t.Errorf("unexpected check state: %+v", state)
+			}
+		})
+	}
+}
+
+func TestHandleAcknowledgeAlert(t *testing.T) {
+	api := newFakeAPI()
+	api.addMember("channel-0", "member")
+	api.addMember("channel-0", "another-member")
+	api.posts["alert"] = &model.Post{Id: "alert", ChannelId: "channel-0", Type: alertPostType}
+	api.posts["message"] = &model.Post{Id: "message", ChannelId: "channel-0"}
+	p := newTestPlugin(api, &configuration{})
+
+	for _, tc := range []struct {
+		name     string
+		userID   string
+		body     string
+		expected int
+		ackedBy  string
+	}{
+		{name: "invalid body", userID: "member", body: "{", expected: http.StatusBadRequest},
+		{name: "unknown post", userID: "member", body: `{"post_id":"unknown"}`, expected: http.StatusNotFound},
+		{name: "not an alert", userID: "member", body: `{"post_id":"message"}`, expected: http.StatusNotFound},
+		{name: "not a member of the channel", userID: "stranger", body: `{"post_id":"alert"}`, expected: http.StatusForbidden},
+		{name: "member", userID: "member", body: `{"post_id":"alert"}`, expected: http.StatusOK, ackedBy: "member"},
+		{name: "acknowledged already", userID: "another-member", body: `{"post_id":"alert"}`, expected: http.StatusOK, ackedBy: "member"},
+	} {
+		// The cases depend on each other, so they run in order.
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/alerts/acknowledge", strings.NewReader(tc.body))
+		r.Header.Set("Mattermost-User-ID", tc.userID)
+
+		p.ServeHTTP(nil, w, r)
+
+		if w.Code != tc.expected {
+			t.Fatalf("%s: expected status %d, got %d", tc.name, tc.expected, w.Code)
+		}
+		if tc.ackedBy == "" {
+			continue
+		}
+		var props map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&props); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if props[propAcknowledgedBy] != tc.ackedBy {
+			t.Errorf("%s: expected the alert to be acknowledged by %s, got %v", tc.name, tc.ackedBy, props[propAcknowledgedBy])
+		}
+	}
+	if api.posts["message"].GetProp(propAcknowledgedBy) != nil {
+		t.Error("expected the regular post not to be acknowledged")
+	}
+}
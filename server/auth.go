@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The methods the webhook calls can be authenticated with in addition to the query string seed.
+const (
+	authMethodQuery  = ""
+	authMethodHeader = "header"
+	authMethodBasic  = "basic"
+	authMethodHMAC   = "hmac"
+
+	defaultSeedHeader      = "X-Pingdom-Seed"
+	defaultSignatureHeader = "X-Pingdom-Signature"
+)
+
+// isValidAuthMethod checks the AuthMethod of the hook configuration.
+func isValidAuthMethod(method string) error {
+	switch method {
+	case authMethodQuery, authMethodHeader, authMethodBasic, authMethodHMAC:
+		return nil
+	}
+	return fmt.Errorf("unknown Auth Method %q", method)
+}
+
+// getAuthHeader returns the header which carries the seed ("header") or the signature ("hmac").
+func (ac *pingdomHookConfig) getAuthHeader() string {
+	if ac.AuthHeader != "" {
+		return ac.AuthHeader
+	}
+	if ac.AuthMethod == authMethodHMAC {
+		return defaultSignatureHeader
+	}
+	return defaultSeedHeader
+}
+
+// authenticate checks whether the request is authenticated for the hook and returns the seed it
+// had been authenticated with: either the current seed or the retired one which is not expired yet.
+func (ac *pingdomHookConfig) authenticate(r *http.Request, body []byte) (string, bool) {
+	for _, seed := range ac.validSeeds(time.Now()) {
+		if ac.authenticateWithSeed(r, body, seed) {
+			return seed, true
+		}
+	}
+	return "", false
+}
+
+// authenticateWithSeed checks the request against the single seed. The seed is the secret for every
+// method: it is compared with the query string, the header or the Basic password, and it is the key
+// of the HMAC-SHA256 signature of the body.
+func (ac *pingdomHookConfig) authenticateWithSeed(r *http.Request, body []byte, secret string) bool {
+	if secret == "" {
+		return false
+	}
+
+	if !ac.DisableQuerySeed {
+		if seed := r.URL.Query().Get("seed"); seed != "" && secureEqual(seed, secret) {
+			return true
+		}
+	}
+
+	switch ac.AuthMethod {
+	case authMethodHeader:
+		seed := r.Header.Get(ac.getAuthHeader())
+		return seed != "" && secureEqual(seed, secret)
+	case authMethodBasic:
+		username, password, ok := r.BasicAuth()
+		// Both are compared to not leak which one is wrong via the timing.
+		usernameOK := secureEqual(username, ac.BasicUsername)
+		passwordOK := secureEqual(password, secret)
+		return ok && usernameOK && passwordOK
+	case authMethodHMAC:
+		signature := strings.TrimPrefix(r.Header.Get(ac.getAuthHeader()), "sha256=")
+		return signature != "" && verifySignature(body, signature, secret)
+	}
+
+	return false
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// verifySignature checks the hex-encoded HMAC-SHA256 signature of the body.
+func verifySignature(body []byte, signature, secret string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	const seed = "12345678901234567890axqiytMrAlY"
+	body := []byte(`{"check_id":1}`)
+
+	mac := hmac.New(sha256.New, []byte(seed))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	for name, tc := range map[string]struct {
+		config   pingdomHookConfig
+		url      string
+		headers  map[string]string
+		username string
+		password string
+		expected bool
+	}{
+		"query seed": {
+			config:   pingdomHookConfig{Seed: seed},
+			url:      "/api/webhook?seed=" + seed,
+			expected: true,
+		},
+		"wrong query seed": {
+			config:   pingdomHookConfig{Seed: seed},
+			url:      "/api/webhook?seed=wrong",
+			expected: false,
+		},
+		"disabled query seed": {
+			config:   pingdomHookConfig{Seed: seed, AuthMethod: authMethodHeader, DisableQuerySeed: true},
+			url:      "/api/webhook?seed=" + seed,
+			expected: false,
+		},
+		"default header": {
+			config:   pingdomHookConfig{Seed: seed, AuthMethod: authMethodHeader},
+			headers:  map[string]string{"X-Pingdom-Seed": seed},
+			expected: true,
+		},
+		"custom header": {
+			config:   pingdomHookConfig{Seed: seed, AuthMethod: authMethodHeader, AuthHeader: "X-Token"},
+			headers:  map[string]string{"X-Token": seed},
+			expected: true,
+		},
+		"header without the method": {
+			config:   pingdomHookConfig{Seed: seed},
+			headers:  map[string]string{"X-Pingdom-Seed": seed},
+			expected: false,
+		},
+		"basic": {
+			config:   pingdomHookConfig{Seed: seed, AuthMethod: authMethodBasic, BasicUsername: "pingdom"},
+			username: "pingdom",
+			password: seed,
+			expected: true,
+		},
+		"basic with the wrong username": {
+			config:   pingdomHookConfig{Seed: seed, AuthMethod: authMethodBasic, BasicUsername: "pingdom"},
+			username: "admin",
+			password: seed,
+			expected: false,
+		},
+		"hmac": {
+			config:   pingdomHookConfig{Seed: seed, AuthMethod: authMethodHMAC},
+			headers:  map[string]string{"X-Pingdom-Signature": "sha256=" + signature},
+			expected: true,
+		},
+		"hmac of another body": {
+			config:   pingdomHookConfig{Seed: seed, AuthMethod: authMethodHMAC},
+			headers:  map[string]string{"X-Pingdom-Signature": strings.Repeat("0", len(signature))},
+			expected: false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			url := tc.url
+			if url == "" {
+				url = "/api/webhook"
+			}
+			r := httptest.NewRequest("POST", url, nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			if tc.username != "" {
+				r.SetBasicAuth(tc.username, tc.password)
+			}
+
+			if _, got := tc.config.authenticate(r, body); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+)
+
+// The targets the hook posts the alerts to.
+const (
+	targetChannel = "channel"
+	targetDirect  = "direct"
+	targetGroup   = "group"
+)
+
+// maxGroupMessageUsers is the number of the users the group message can have besides the bot.
+const maxGroupMessageUsers = model.ChannelGroupMaxUsers - 1
+
+const (
+	// hookChannelVerifyInterval is how often the cached channel is checked for being renamed or
+	// archived. The server has no hooks for these changes.
+	hookChannelVerifyInterval = 10 * time.Minute
+	// hookChannelNotifyInterval limits the reports of the channel which can not be resolved.
+	hookChannelNotifyInterval = time.Hour
+)
+
+// hookChannels caches the channels the hooks post to. The channel is resolved (or created) on
+// activation and again on demand, when it failed before or the cache had expired.
+type hookChannels struct {
+	lock sync.Mutex
+	// key - pingdomHookConfig id, value - existing or created channel received from api
+	entries map[string]hookChannel
+	// notifiedAt is when the admins were told the channel of the hook can not be resolved.
+	notifiedAt map[string]time.Time
+	// renamedTo is the name the channel of the hook had been renamed to, as reported to the admins.
+	renamedTo map[string]string
+}
+
+type hookChannel struct {
+	id         string
+	verifiedAt time.Time
+}
+
+// reset drops every cached channel, e.g. when the configuration changes. The reports are kept, so
+// saving the settings does not repeat them.
+func (c *hookChannels) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = make(map[string]hookChannel)
+}
+
+func (c *hookChannels) get(hookID string) (hookChannel, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[hookID]
+	return entry, ok
+}
+
+func (c *hookChannels) set(hookID, channelID string, now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]hookChannel)
+	}
+	c.entries[hookID] = hookChannel{id: channelID, verifiedAt: now}
+	delete(c.notifiedAt, hookID)
+}
+
+// shouldReportRename tells if the rename of the channel of the hook to the name is not reported yet.
+func (c *hookChannels) shouldReportRename(hookID, name string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.renamedTo[hookID] == name {
+		return false
+	}
+	if c.renamedTo == nil {
+		c.renamedTo = make(map[string]string)
+	}
+	c.renamedTo[hookID] = name
+	return true
+}
+
+func (c *hookChannels) invalidate(hookID string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, hookID)
+}
+
+// hooksOf returns the hooks which post to the channel.
+func (c *hookChannels) hooksOf(channelID string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var hookIDs []string
+	for hookID, entry := range c.entries {
+		if entry.id == channelID {
+			hookIDs = append(hookIDs, hookID)
+		}
+	}
+	return hookIDs
+}
+
+// shouldNotify tells if the failure to resolve the channel of the hook should be reported now.
+func (c *hookChannels) shouldNotify(hookID string, now time.Time) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.notifiedAt == nil {
+		c.notifiedAt = make(map[string]time.Time)
+	}
+	if now.Sub(c.notifiedAt[hookID]) < hookChannelNotifyInterval {
+		return false
+	}
+	c.notifiedAt[hookID] = now
+	return true
+}
+
+// GetTarget returns where the hook posts the alerts to.
+func (ac *pingdomHookConfig) GetTarget() string {
+	if ac.Target == "" {
+		return targetChannel
+	}
+	return ac.Target
+}
+
+// GetUsernames returns the users of the direct or the group message target.
+func (ac *pingdomHookConfig) GetUsernames() []string {
+	var usernames []string
+	for _, username := range strings.Split(ac.Channel, ",") {
+		if username = strings.TrimPrefix(strings.TrimSpace(username), "@"); username != "" {
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+// GetTargetMention renders the target of the hook for the messages: ~channel for the channel,
+// @user for the direct message and the list of the users for the group message.
+func (ac *pingdomHookConfig) GetTargetMention() string {
+	if ac.GetTarget() == targetChannel {
+		return "~" + ac.Channel
+	}
+	usernames := ac.GetUsernames()
+	mentions := make([]string, len(usernames))
+	for i, username := range usernames {
+		mentions[i] = "@" + username
+	}
+	return strings.Join(mentions, ", ")
+}
+
+func (ac *pingdomHookConfig) isValidTarget() error {
+	switch ac.GetTarget() {
+	case targetChannel:
+		return nil
+	case targetDirect:
+		if len(ac.GetUsernames()) != 1 {
+			return errors.New("must set a single username as the Channel of the direct message")
+		}
+		return nil
+	case targetGroup:
+		if n := len(ac.GetUsernames()); n < 2 || n > maxGroupMessageUsers {
+			return fmt.Errorf("must set from 2 to %d usernames as the Channel of the group message", maxGroupMessageUsers)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown Target %q", ac.Target)
+}
+
+// getUserIDs returns the IDs of the users of the direct or the group message target.
+func (p *Plugin) getUserIDs(config pingdomHookConfig) ([]string, error) {
+	var userIDs []string
+	for _, username := range config.GetUsernames() {
+		user, appErr := p.API.GetUserByUsername(username)
+		if appErr != nil {
+			return nil, fmt.Errorf("failed to get the user %s: %w", username, appErr)
+		}
+		userIDs = append(userIDs, user.Id)
+	}
+	return userIDs, nil
+}
+
+// ensureDirectChannel returns the direct message of the bot with the user.
+func (p *Plugin) ensureDirectChannel(config pingdomHookConfig) (string, error) {
+	userIDs, err := p.getUserIDs(config)
+	if err != nil {
+		return "", err
+	}
+	channel, appErr := p.API.GetDirectChannel(userIDs[0], p.BotUserID)
+	if appErr != nil {
+		return "", fmt.Errorf("failed to get the direct message: %w", appErr)
+	}
+	return channel.Id, nil
+}
+
+// ensureGroupChannel returns the group message of the bot with the users.
+func (p *Plugin) ensureGroupChannel(config pingdomHookConfig) (string, error) {
+	userIDs, err := p.getUserIDs(config)
+	if err != nil {
+		return "", err
+	}
+	channel, appErr := p.API.GetGroupChannel(append(userIDs, p.BotUserID))
+	if appErr != nil {
+		return "", fmt.Errorf("failed to get the group message: %w", appErr)
+	}
+	return channel.Id, nil
+}
+
+// ensureBotChannelMember adds the bot to the channel unless it is the member already.
+func (p *Plugin) ensureBotChannelMember(channelID string) error {
+	if _, appErr := p.API.GetChannelMember(channelID, p.BotUserID); appErr == nil {
+		return nil
+	}
+	if _, appErr := p.API.AddChannelMember(channelID, p.BotUserID); appErr != nil {
+		return fmt.Errorf("failed to add the bot to the private channel: %w", appErr)
+	}
+	return nil
+}
+
+// hookChannelRecord is the channel the hook had been resolved to, it is kept in the KV store, so the
+// renamed channel is followed after the restart as well. It is followed while the target, the team
+// and the channel of the hook stay the same.
+type hookChannelRecord struct {
+	ChannelID string `json:"channel_id"`
+	Target    string `json:"target"`
+	Team      string `json:"team"`
+	Channel   string `json:"channel"`
+}
+
+func hookChannelKey(hookID string) string {
+	return fmt.Sprintf("hook_channel_%s", hookID)
+}
+
+func newHookChannelRecord(config pingdomHookConfig, channelID string) hookChannelRecord {
+	return hookChannelRecord{ChannelID: channelID, Target: config.GetTarget(), Team: config.Team, Channel: config.Channel}
+}
+
+// getHookChannelID returns the channel the hook posts to, resolving (or creating) it if it is not
+// known yet.
+func (p *Plugin) getHookChannelID(config pingdomHookConfig) (string, error) {
+	return p.resolveHookChannel(config, true)
+}
+
+// lookupHookChannelID returns the channel the hook posts to without creating it, for the paths which
+// only read, e.g. the permission checks of the users.
+func (p *Plugin) lookupHookChannelID(config pingdomHookConfig) (string, error) {
+	return p.resolveHookChannel(config, false)
+}
+
+// resolveHookChannel returns the cached channel of the hook, it is checked again every
+// hookChannelVerifyInterval. The known channel is followed by its ID, so the renamed one keeps
+// receiving the alerts (the admins are told to update the settings), and the archived one is
+// reported instead of being created again. The channel is looked up by the name otherwise.
+func (p *Plugin) resolveHookChannel(config pingdomHookConfig, create bool) (string, error) {
+	now := time.Now()
+	entry, cached := p.channels.get(config.ID)
+	if cached && now.Sub(entry.verifiedAt) < hookChannelVerifyInterval {
+		return entry.id, nil
+	}
+
+	knownID := entry.id
+	if !cached {
+		var record *hookChannelRecord
+		if err := p.client.KV.Get(hookChannelKey(config.ID), &record); err != nil {
+			return "", fmt.Errorf("failed to get the channel of the hook %s: %w", config.ID, err)
+		}
+		if record != nil && *record == newHookChannelRecord(config, record.ChannelID) {
+			knownID = record.ChannelID
+		}
+	}
+
+	if knownID != "" {
+		channelID, found, err := p.followHookChannel(config, knownID, create, now)
+		if found || err != nil {
+			return channelID, err
+		}
+		p.API.LogWarn("The channel of the hook had been deleted, resolving it again", "hook_id", config.ID, "channel_id", knownID)
+		p.channels.invalidate(config.ID)
+	}
+
+	var channelID string
+	var err error
+	if create {
+		channelID, err = p.ensureAlertChannelExists(config)
+	} else {
+		channelID, err = p.findAlertChannel(config)
+	}
+	if err != nil {
+		if create {
+			p.reportUnresolvedChannel(config, err, now)
+		}
+		return "", fmt.Errorf("failed to resolve the channel of the hook %s: %w", config.ID, err)
+	}
+
+	p.channels.set(config.ID, channelID, now)
+	if _, err := p.client.KV.Set(hookChannelKey(config.ID), newHookChannelRecord(config, channelID)); err != nil {
+		p.API.LogWarn("failed to store the channel of the hook", "hook_id", config.ID, "err", err.Error())
+	}
+	return channelID, nil
+}
+
+// followHookChannel checks the known channel of the hook. It returns false if the channel does not
+// exist anymore.
+func (p *Plugin) followHookChannel(config pingdomHookConfig, channelID string, create bool, now time.Time) (string, bool, error) {
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		if appErr.StatusCode == http.StatusNotFound {
+			return "", false, nil
+		}
+		return "", true, fmt.Errorf("failed to get the channel of the hook %s: %w", config.ID, appErr)
+	}
+
+	if channel.DeleteAt != 0 {
+		p.channels.invalidate(config.ID)
+		err := fmt.Errorf("the channel %s is archived", channel.Name)
+		if create {
+			p.reportUnresolvedChannel(config, err, now)
+		}
+		return "", true, fmt.Errorf("failed to resolve the channel of the hook %s: %w", config.ID, err)
+	}
+
+	if create && channel.Type == model.ChannelTypePrivate {
+		if err := p.ensureBotChannelMember(channel.Id); err != nil {
+			return "", true, err
+		}
+	}
+	if config.GetTarget() == targetChannel && channel.Name != config.Channel {
+		p.reportRenamedChannel(config, channel)
+	}
+	p.channels.set(config.ID, channel.Id, now)
+	return channel.Id, true, nil
+}
+
+// findAlertChannel looks the channel of the hook up by the name, it is not created.
+func (p *Plugin) findAlertChannel(config pingdomHookConfig) (string, error) {
+	if err := config.IsValid(); err != nil {
+		return "", fmt.Errorf("Pingdom Configuration is invalid: %w", err)
+	}
+
+	teamID, name := "", config.Channel
+	switch config.GetTarget() {
+	case targetDirect, targetGroup:
+		userIDs, err := p.getUserIDs(config)
+		if err != nil {
+			return "", err
+		}
+		if config.GetTarget() == targetDirect {
+			name = model.GetDMNameFromIds(userIDs[0], p.BotUserID)
+		} else {
+			name = model.GetGroupNameFromUserIds(append(userIDs, p.BotUserID))
+		}
+	default:
+		team, appErr := p.API.GetTeamByName(config.Team)
+		if appErr != nil {
+			return "", fmt.Errorf("failed to get team: %w", appErr)
+		}
+		teamID = team.Id
+	}
+
+	channel, appErr := p.API.GetChannelByName(teamID, name, false)
+	if appErr != nil {
+		return "", fmt.Errorf("failed to get the channel: %w", appErr)
+	}
+	return channel.Id, nil
+}
+
+// reportRenamedChannel tells the system admins the channel of the hook had been renamed, once per
+// rename. The alerts keep going to the renamed channel.
+func (p *Plugin) reportRenamedChannel(config pingdomHookConfig, channel *model.Channel) {
+	if !p.channels.shouldReportRename(config.ID, channel.Name) {
+		return
+	}
+	p.API.LogWarn("The channel of the hook had been renamed", "hook_id", config.ID, "channel", config.Channel, "renamed_to", channel.Name)
+	p.notifySystemAdmins(fmt.Sprintf(":information_source: The channel `%s` of the hook `%s` had been renamed to ~%s, the alerts keep "+
+		"going to it. Update the channel of the hook in the plugin settings.", escapeCode(config.Channel), escapeCode(config.ID), channel.Name))
+}
+
+// reportUnresolvedChannel tells the system admins the alerts of the hook can not be posted.
+func (p *Plugin) reportUnresolvedChannel(config pingdomHookConfig, err error, now time.Time) {
+	p.API.LogError("Failed to resolve the channel of the hook", "hook_id", config.ID, "team", config.Team, "channel", config.Channel, "err", err.Error())
+	if !p.channels.shouldNotify(config.ID, now) {
+		return
+	}
+	target := fmt.Sprintf("the channel `%s` of the team `%s`", escapeCode(config.Channel), escapeCode(config.Team))
+	if config.GetTarget() != targetChannel {
+		target = fmt.Sprintf("the message to %s", config.GetTargetMention())
+	}
+	p.notifySystemAdmins(fmt.Sprintf(":warning: The alerts of the hook `%s` can not be posted: %s "+
+		"can not be resolved (%s). Check the target of the hook in the plugin settings, the alerts are retried meanwhile.",
+		escapeCode(config.ID), target, escapeMarkdown(err.Error())))
+}
+
+// resolveHookChannels resolves the channels of every enabled hook from scratch.
+func (p *Plugin) resolveHookChannels() {
+	p.channels.reset()
+	for _, config := range p.getConfiguration().PingdomHooksConfigs {
+		if config.Disabled {
+			continue
+		}
+		if _, err := p.getHookChannelID(config); err != nil {
+			p.API.LogWarn("Failed to ensure alert channel", "hook_id", config.ID, "error", err.Error())
+		}
+	}
+}
+
+// ChannelHasBeenCreated maps the hooks waiting for their channel to it.
+func (p *Plugin) ChannelHasBeenCreated(_ *plugin.Context, channel *model.Channel) {
+	for _, config := range p.getConfiguration().PingdomHooksConfigs {
+		if config.GetTarget() != targetChannel || config.Channel != channel.Name {
+			continue
+		}
+		if _, ok := p.channels.get(config.ID); ok {
+			continue
+		}
+		team, appErr := p.API.GetTeamByName(config.Team)
+		if appErr != nil || team.Id != channel.TeamId {
+			continue
+		}
+		p.API.LogInfo("The channel of the hook had been created", "hook_id", config.ID, "channel_id", channel.Id)
+		p.channels.set(config.ID, channel.Id, time.Now())
+	}
+}
+
+// UserHasLeftChannel drops the cached channel the bot had been removed from, it is resolved again
+// by the next alert.
+func (p *Plugin) UserHasLeftChannel(_ *plugin.Context, channelMember *model.ChannelMember, _ *model.User) {
+	if channelMember.UserId != p.BotUserID {
+		return
+	}
+	for _, hookID := range p.channels.hooksOf(channelMember.ChannelId) {
+		p.API.LogInfo("The bot had been removed from the channel of the hook", "hook_id", hookID, "channel_id", channelMember.ChannelId)
+		p.channels.invalidate(hookID)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+func TestHookChannels(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	var c hookChannels
+
+	c.set("0", "channel-a", now)
+	c.set("1", "channel-a", now)
+	c.set("2", "channel-b", now)
+	if hooks := c.hooksOf("channel-a"); len(hooks) != 2 {
+		t.Errorf("expected 2 hooks of the channel, got %v", hooks)
+	}
+
+	c.invalidate("1")
+	if _, ok := c.get("1"); ok {
+		t.Error("expected the invalidated channel to be dropped")
+	}
+	if hooks := c.hooksOf("channel-a"); len(hooks) != 1 || hooks[0] != "0" {
+		t.Errorf("expected the hook 0 only, got %v", hooks)
+	}
+
+	if !c.shouldNotify("3", now) {
+		t.Error("expected the first failure to be reported")
+	}
+	if c.shouldNotify("3", now.Add(30*time.Minute)) {
+		t.Error("expected the failure not to be reported again within an hour")
+	}
+	if !c.shouldNotify("3", now.Add(61*time.Minute)) {
+		t.Error("expected the failure to be reported again after an hour")
+	}
+
+	c.shouldNotify("4", now)
+	c.set("4", "channel-c", now)
+	if !c.shouldNotify("4", now.Add(time.Minute)) {
+		t.Error("expected the next failure to be reported once the channel had been resolved")
+	}
+
+	c.reset()
+	if _, ok := c.get("0"); ok {
+		t.Error("expected the channels to be dropped on reset")
+	}
+	if c.shouldNotify("4", now.Add(2*time.Minute)) {
+		t.Error("expected the reports to survive the reset")
+	}
+}
+
+func TestHookTargetIsValid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config    pingdomHookConfig
+		usernames []string
+		valid     bool
+	}{
+		"channel by default": {
+			config: pingdomHookConfig{Team: "team", Channel: "alerts", Seed: "seed"},
+			valid:  true,
+		},
+		"channel without the team": {
+			config: pingdomHookConfig{Target: targetChannel, Channel: "alerts", Seed: "seed"},
+		},
+		"direct message without the team": {
+			config:    pingdomHookConfig{Target: targetDirect, Channel: "@alice", Seed: "seed"},
+			usernames: []string{"alice"},
+			valid:     true,
+		},
+		"direct message to several users": {
+			config:    pingdomHookConfig{Target: targetDirect, Channel: "alice,bob", Seed: "seed"},
+			usernames: []string{"alice", "bob"},
+		},
+		"group message": {
+			config:    pingdomHookConfig{Target: targetGroup, Channel: "@alice, bob,", Seed: "seed"},
+			usernames: []string{"alice", "bob"},
+			valid:     true,
+		},
+		"group message to a single user": {
+			config:    pingdomHookConfig{Target: targetGroup, Channel: "alice", Seed: "seed"},
+			usernames: []string{"alice"},
+		},
+		"group message to too many users": {
+			config:    pingdomHookConfig{Target: targetGroup, Channel: "a,b,c,d,e,f,g,h", Seed: "seed"},
+			usernames: []string{"a", "b", "c", "d", "e", "f", "g", "h"},
+		},
+		"unknown target": {
+			config: pingdomHookConfig{Target: "thread", Team: "team", Channel: "alerts", Seed: "seed"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.config.IsValid()
+			if tc.valid && err != nil {
+				t.Errorf("expected the config to be valid, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected the config to be invalid")
+			}
+			if tc.usernames != nil && !slices.Equal(tc.config.GetUsernames(), tc.usernames) {
+				t.Errorf("expected the usernames %v, got %v", tc.usernames, tc.config.GetUsernames())
+			}
+		})
+	}
+}
+
+func TestResolveHookChannel(t *testing.T) {
+	api := newFakeAPI()
+	api.teams["team"] = &model.Team{Id: "team-id", Name: "team"}
+	config := pingdomHookConfig{ID: "0", Team: "team", Channel: "alerts", Seed: "seed-0"}
+	p := newTestPlugin(api, &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{"0": config}})
+
+	if _, err := p.lookupHookChannelID(config); err == nil || len(api.channels) != 0 {
+		t.Fatalf("expected the lookup not to create the channel, got %v, %d channels", err, len(api.channels))
+	}
+
+	channelID, err := p.getHookChannelID(config)
+	if err != nil || api.channels[channelID] == nil || api.channels[channelID].Name != "alerts" {
+		t.Fatalf("expected the channel to be created, got %q, %v", channelID, err)
+	}
+
+	// The renamed channel is followed by its ID after the restart as well.
+	api.channels[channelID].Name = "alerts-renamed"
+	p.channels.reset()
+	if renamedID, err := p.getHookChannelID(config); err != nil || renamedID != channelID || len(api.channels) != 1 {
+		t.Errorf("expected the renamed channel to be followed, got %q, %v, %d channels", renamedID, err, len(api.channels))
+	}
+	if p.channels.shouldReportRename("0", "alerts-renamed") {
+		t.Error("expected the rename to be reported")
+	}
+
+	// The archived channel is not created again.
+	api.channels[channelID].Name = "alerts"
+	api.channels[channelID].DeleteAt = 1
+	p.channels.reset()
+	if _, err := p.getHookChannelID(config); err == nil || len(api.channels) != 1 {
+		t.Errorf("expected the archived channel to be reported, got %v, %d channels", err, len(api.channels))
+	}
+
+	// The hook which is moved to another channel resolves it by the name.
+	config.Channel = "other"
+	otherID, err := p.getHookChannelID(config)
+	if err != nil || otherID == channelID || api.channels[otherID].Name != "other" {
+		t.Errorf("expected the other channel to be created, got %q, %v", otherID, err)
+	}
+}
+
+func TestGetTargetMention(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config   pingdomHookConfig
+		expected string
+	}{
+		"channel":        {config: pingdomHookConfig{Team: "team", Channel: "alerts"}, expected: "~alerts"},
+		"direct message": {config: pingdomHookConfig{Target: targetDirect, Channel: "@alice"}, expected: "@alice"},
+		"group message":  {config: pingdomHookConfig{Target: targetGroup, Channel: "alice, @bob,carol"}, expected: "@alice, @bob, @carol"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := tc.config.GetTargetMention(); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -17,7 +17,8 @@ func BuildInfoAutocomplete(cmd string) *model.AutocompleteData {
 	return model.NewAutocompleteData(cmd, "", "Display build info")
 }
 
-func BuildInfo(manifest model.Manifest) (string, error) {
+// BuildInfo renders the build information, the build time is shown in the given location.
+func BuildInfo(manifest model.Manifest, loc *time.Location) (string, error) {
 	info, ok := debug.ReadBuildInfo()
 	if !ok {
 		return "", errors.New("failed to read build info")
@@ -67,7 +68,7 @@ func BuildInfo(manifest model.Manifest) (string, error) {
 			manifest.Version,
 			commit,
 			dirtyText,
-			buildTime.Format(time.RFC1123),
+			buildTime.In(loc).Format(time.RFC1123),
 			info.GoVersion),
 		nil
 }
@@ -2,20 +2,33 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 
 	"github.com/zentavr/mattermost-plugin-pingdom/server/command"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
 )
 
 const (
-	actionHelp  = "help"
-	actionAbout = "about"
+	actionHelp        = "help"
+	actionAbout       = "about"
+	actionDeadLetters = "deadletters"
+	actionReplay      = "replay"
+	actionHistory     = "history"
+	actionTest        = "test"
+	actionHooks       = "hooks"
 
 	helpMsg = `run:
 	/pingdom status - display status information (not implemented yet =])
+	/pingdom history <check> [--since 7d] - list the state changes of the check (by ID or name)
+	/pingdom hooks - list the hooks with their delivery stats (system admins only)
+	/pingdom deadletters - list the alerts which failed to be posted (system admins only)
+	/pingdom replay <id|all> - post the failed alerts again (system admins only)
+	/pingdom test [hook] [--state DOWN|UP] [--type HTTP|DNS|...] - send a test alert through the hook (system admins only)
 	/pingdom help - display Slash Command help text"
 	/pingdom about - display build information
 	`
@@ -30,7 +43,7 @@ func (p *Plugin) getCommand() (*model.Command, error) {
 	return &model.Command{
 		Trigger:              "pingdom",
 		AutoComplete:         true,
-		AutoCompleteDesc:     fmt.Sprintf("Available commands: status, %s, %s", actionHelp, actionAbout),
+		AutoCompleteDesc:     fmt.Sprintf("Available commands: status, %s, %s, %s, %s, %s, %s, %s", actionHistory, actionHooks, actionDeadLetters, actionReplay, actionTest, actionHelp, actionAbout),
 		AutoCompleteHint:     "[command]",
 		AutocompleteData:     getAutocompleteData(),
 		AutocompleteIconData: iconData,
@@ -38,11 +51,43 @@ func (p *Plugin) getCommand() (*model.Command, error) {
 }
 
 func getAutocompleteData() *model.AutocompleteData {
-	root := model.NewAutocompleteData("pingdom", "[command]", fmt.Sprintf("Available commands: status, %s, %s", actionHelp, actionAbout))
+	root := model.NewAutocompleteData("pingdom", "[command]", fmt.Sprintf("Available commands: status, %s, %s, %s, %s, %s, %s, %s", actionHistory, actionHooks, actionDeadLetters, actionReplay, actionTest, actionHelp, actionAbout))
 
 	status := model.NewAutocompleteData("status", "", "List the status information")
 	root.AddCommand(status)
 
+	history := model.NewAutocompleteData(actionHistory, "<check> [--since 7d]", "List the state changes of the check")
+	history.AddTextArgument("The ID or the name of the check", "<check>", "")
+	history.AddNamedTextArgument("since", "The period to list, e.g. 7d or 36h", "7d", "", false)
+	root.AddCommand(history)
+
+	hooks := model.NewAutocompleteData(actionHooks, "", "List the hooks with their delivery stats")
+	hooks.RoleID = model.SystemAdminRoleId
+	root.AddCommand(hooks)
+
+	deadLetters := model.NewAutocompleteData(actionDeadLetters, "", "List the alerts which failed to be posted")
+	deadLetters.RoleID = model.SystemAdminRoleId
+	root.AddCommand(deadLetters)
+
+	replay := model.NewAutocompleteData(actionReplay, "<id|all>", "Post the failed alerts again")
+	replay.RoleID = model.SystemAdminRoleId
+	replay.AddTextArgument("The ID of the dead letter or all", "<id|all>", "")
+	root.AddCommand(replay)
+
+	test := model.NewAutocompleteData(actionTest, "[hook] [--state DOWN|UP] [--type HTTP]", "Send a test alert through the hook")
+	test.RoleID = model.SystemAdminRoleId
+	test.AddTextArgument("The ID of the hook (the one of this channel by default)", "[hook]", "")
+	test.AddNamedStaticListArgument("state", "The state of the test alert", false, []model.AutocompleteListItem{
+		{Item: "DOWN", HelpText: "The check went down"},
+		{Item: "UP", HelpText: "The check is up again"},
+	})
+	types := make([]model.AutocompleteListItem, 0, len(testCheckParams))
+	for _, checkType := range testCheckTypes() {
+		types = append(types, model.AutocompleteListItem{Item: checkType})
+	}
+	test.AddNamedStaticListArgument("type", "The check type of the test alert", false, types)
+	root.AddCommand(test)
+
 	help := model.NewAutocompleteData(actionHelp, "", "Display Slash Command help text")
 	root.AddCommand(help)
 
@@ -92,8 +137,18 @@ func (p *Plugin) executeCommand(args *model.CommandArgs) string {
 	switch action {
 	case "status":
 		msg, err = p.handleStatus(args)
+	case actionHistory:
+		msg, err = p.handleHistory(args, split[2:])
+	case actionHooks:
+		msg, err = p.handleHooks(args)
+	case actionDeadLetters:
+		msg, err = p.handleDeadLetters(args)
+	case actionReplay:
+		msg, err = p.handleReplay(args, split[2:])
+	case actionTest:
+		msg, err = p.handleTest(args, split[2:])
 	case actionAbout:
-		msg, err = command.BuildInfo(Manifest)
+		msg, err = command.BuildInfo(Manifest, p.getUserLocation(args.UserId))
 	case actionHelp:
 		msg = helpMsg
 	default:
@@ -110,3 +165,249 @@ func (p *Plugin) executeCommand(args *model.CommandArgs) string {
 func (p *Plugin) handleStatus(args *model.CommandArgs) (string, error) {
 	return "Not Implemented yet", nil
 }
+
+func (p *Plugin) handleHooks(args *model.CommandArgs) (string, error) {
+	if !p.API.HasPermissionTo(args.UserId, model.PermissionManageSystem) {
+		return "Only the system admins can list the hooks.", nil
+	}
+
+	hooks := p.getConfiguration().PingdomHooksConfigs
+	if len(hooks) == 0 {
+		return "There are no hooks configured.", nil
+	}
+	ids := make([]string, 0, len(hooks))
+	for id := range hooks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	p.flushHookStats()
+	loc := p.getUserLocation(args.UserId)
+	var sb strings.Builder
+	sb.WriteString("| Hook | Channel | Last Alert | Received | Delivered | Failures | Rejected | Last Error |\n")
+	sb.WriteString("|:-----|:--------|:-----------|---------:|----------:|---------:|---------:|:-----------|\n")
+	for _, id := range ids {
+		config := hooks[id]
+		stats, err := p.getHookStats(id)
+		if err != nil {
+			return "", err
+		}
+
+		channel := config.GetTargetMention()
+		if config.Disabled {
+			channel += " (disabled)"
+		}
+		lastAlert := "never"
+		if stats.LastReceivedAt != 0 {
+			lastAlert = fmt.Sprintf("%s, %s", formatTimeAgo(time.UnixMilli(stats.LastReceivedAt), loc, defaultTimeFormat, time.Now()), escapeMarkdown(stats.LastCheckName))
+		}
+		lastError := "n/a"
+		if stats.LastErrorAt != 0 {
+			lastError = fmt.Sprintf("%s: %s", formatTimeAgo(time.UnixMilli(stats.LastErrorAt), loc, defaultTimeFormat, time.Now()), escapeMarkdown(stats.LastError))
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %d | %d | %d | %d | %s |\n",
+			escapeMarkdown(id), channel, lastAlert,
+			stats.Received, stats.Delivered, stats.Failures, stats.Rejected, lastError))
+	}
+	return sb.String(), nil
+}
+
+func (p *Plugin) handleDeadLetters(args *model.CommandArgs) (string, error) {
+	if !p.API.HasPermissionTo(args.UserId, model.PermissionManageSystem) {
+		return "Only the system admins can list the dead letters.", nil
+	}
+
+	letters, err := p.getDeadLetters()
+	if err != nil {
+		return "", err
+	}
+	if len(letters) == 0 {
+		return "There are no dead letters, every alert had been posted.", nil
+	}
+
+	loc := p.getUserLocation(args.UserId)
+	var sb strings.Builder
+	sb.WriteString("| ID | Hook | Check | State | Failed At | Attempts | Reason |\n")
+	sb.WriteString("|:---|:-----|:------|:------|:----------|---------:|:-------|\n")
+	for _, letter := range letters {
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s | %d | %s |\n",
+			letter.ID,
+			escapeMarkdown(letter.HookID),
+			escapeMarkdown(letter.Message.CheckName),
+			escapeMarkdown(letter.Message.CurrentState),
+			formatTimeAgo(time.UnixMilli(letter.FailedAt), loc, defaultTimeFormat, time.Now()),
+			letter.Attempts,
+			escapeMarkdown(letter.Reason),
+		))
+	}
+	sb.WriteString("\nRun `/pingdom replay <id|all>` to post them again.")
+	return sb.String(), nil
+}
+
+func (p *Plugin) handleReplay(args *model.CommandArgs, params []string) (string, error) {
+	if !p.API.HasPermissionTo(args.UserId, model.PermissionManageSystem) {
+		return "Only the system admins can replay the dead letters.", nil
+	}
+	if len(params) != 1 {
+		return "Please specify the ID of the dead letter or `all`: `/pingdom replay <id|all>`.", nil
+	}
+
+	letters, err := p.getDeadLetters()
+	if err != nil {
+		return "", err
+	}
+
+	var replayed, failed int
+	var failures []string
+	for _, letter := range letters {
+		if params[0] != "all" && letter.ID != params[0] {
+			continue
+		}
+		if err := p.replayDeadLetter(letter); err != nil {
+			failed++
+			failures = append(failures, fmt.Sprintf("- `%s`: %s", letter.ID, escapeMarkdown(err.Error())))
+			continue
+		}
+		replayed++
+	}
+
+	if replayed == 0 && failed == 0 {
+		return fmt.Sprintf("The dead letter `%s` is not found.", escapeCode(params[0])), nil
+	}
+	msg := fmt.Sprintf("Replayed %d dead letter(s).", replayed)
+	if failed > 0 {
+		msg = fmt.Sprintf("%s %d dead letter(s) failed again:\n%s", msg, failed, strings.Join(failures, "\n"))
+	}
+	return msg, nil
+}
+
+func (p *Plugin) handleHistory(args *model.CommandArgs, params []string) (string, error) {
+	since := defaultHistorySince
+	var checkParts []string
+	for i := 0; i < len(params); i++ {
+		value, isSince := strings.CutPrefix(params[i], "--since=")
+		if params[i] == "--since" {
+			if i+1 >= len(params) {
+				return "Please specify the period: `--since 7d`.", nil
+			}
+			i++
+			value, isSince = params[i], true
+		}
+		if !isSince {
+			checkParts = append(checkParts, params[i])
+			continue
+		}
+
+		var err error
+		if since, err = parseSince(value); err != nil {
+			return fmt.Sprintf("The period `%s` is invalid, use e.g. `7d` or `36h`.", escapeCode(value)), nil
+		}
+	}
+	check := strings.Join(checkParts, " ")
+	if check == "" {
+		return "Please specify the ID or the name of the check: `/pingdom history <check> [--since 7d]`.", nil
+	}
+
+	isAdmin := p.API.HasPermissionTo(args.UserId, model.PermissionManageSystem)
+	loc := p.getUserLocation(args.UserId)
+	from := time.Now().Add(-since)
+
+	var sb strings.Builder
+	found := false
+	for hookID, config := range p.getConfiguration().PingdomHooksConfigs {
+		// The history is only shown to the members of the channel the alerts are posted to.
+		if !isAdmin {
+			channelID, err := p.lookupHookChannelID(config)
+			if err != nil {
+				continue
+			}
+			if _, appErr := p.API.GetChannelMember(channelID, args.UserId); appErr != nil {
+				continue
+			}
+		}
+
+		checks, err := p.findHistoryChecks(hookID, check)
+		if err != nil {
+			return "", err
+		}
+		for _, c := range checks {
+			found = true
+			entries, err := p.getHistory(hookID, c.CheckID, from)
+			if err != nil {
+				return "", err
+			}
+
+			sb.WriteString(fmt.Sprintf("#### %s (check %d, hook %s)\n", escapeMarkdown(c.CheckName), c.CheckID, escapeMarkdown(hookID)))
+			if len(entries) == 0 {
+				sb.WriteString("No state changes in this period.\n\n")
+				continue
+			}
+			sb.WriteString("| Changed At | Transition | Description | Probes |\n")
+			sb.WriteString("|:-----------|:-----------|:------------|:-------|\n")
+			for _, entry := range entries {
+				probes := naString(&entry.FirstProbe)
+				if entry.SecondProbe != "" {
+					probes = fmt.Sprintf("%s, %s", probes, escapeMarkdown(entry.SecondProbe))
+				}
+				sb.WriteString(fmt.Sprintf("| %s | %s → %s | %s | %s |\n",
+					formatTimeAgo(time.UnixMilli(entry.ChangedAt), loc, defaultTimeFormat, time.Now()),
+					naString(&entry.PreviousState),
+					naString(&entry.CurrentState),
+					naString(&entry.Description),
+					probes,
+				))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	if !found {
+		return fmt.Sprintf("No history of the check `%s` is found.", escapeCode(check)), nil
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func (p *Plugin) handleTest(args *model.CommandArgs, params []string) (string, error) {
+	if !p.API.HasPermissionTo(args.UserId, model.PermissionManageSystem) {
+		return "Only the system admins can send the test alerts.", nil
+	}
+
+	hookID, state, checkType := "", "DOWN", pingdom.CheckTypeHTTP
+	for i := 0; i < len(params); i++ {
+		name, value, hasValue := strings.Cut(params[i], "=")
+		if name != "--state" && name != "--type" {
+			if hookID != "" {
+				return "Please specify a single hook: `/pingdom test [hook] [--state DOWN|UP] [--type HTTP]`.", nil
+			}
+			hookID = params[i]
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(params) {
+				return fmt.Sprintf("Please specify the value of `%s`.", name), nil
+			}
+			i++
+			value = params[i]
+		}
+		if name == "--state" {
+			state = value
+		} else {
+			checkType = value
+		}
+	}
+
+	config, err := p.findTestHook(hookID, args.ChannelId)
+	if err != nil {
+		return fmt.Sprintf("Failed to send the test alert: %s.", escapeMarkdown(err.Error())), nil
+	}
+
+	sentBy := args.UserId
+	if user, appErr := p.API.GetUser(args.UserId); appErr == nil {
+		sentBy = "@" + user.Username
+	}
+	code, err := p.sendTestAlert(config, checkType, state, sentBy)
+	if err != nil {
+		return fmt.Sprintf("Failed to send the test alert through the hook `%s`: %s.", escapeCode(config.ID), escapeMarkdown(err.Error())), nil
+	}
+	return fmt.Sprintf("The test alert had been accepted by the hook `%s` (HTTP %d), it is posted to %s shortly.", escapeCode(config.ID), code, config.GetTargetMention()), nil
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
-	//	"strings"
+	"time"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
 )
 
 // configuration captures the plugin's external configuration as exposed in the Mattermost server
@@ -20,6 +23,13 @@ import (
 // copy appropriate for your types.
 type configuration struct {
 	PingdomHooksConfigs map[string]pingdomHookConfig
+
+	// MetricsToken is the bearer token the Prometheus scraper authenticates /metrics with, the
+	// system admins are allowed without it (see metrics.go).
+	MetricsToken string
+
+	// seedIndex is computed from PingdomHooksConfigs in OnConfigurationChange.
+	seedIndex *seedIndex
 }
 
 type pingdomHookConfig struct {
@@ -29,10 +39,69 @@ type pingdomHookConfig struct {
 	Token    string
 	Seed     string
 	Team     string
+
+	// Target is where the alerts are posted to (see channels.go): the Channel of the Team by default,
+	// the direct message with the user or the group message with the users named in Channel.
+	Target string
+	// PrivateChannel creates the missing Channel as the private one.
+	PrivateChannel bool
+
+	// Provider is the uptime monitoring service the hook receives the alerts from (see the uptime
+	// package), Pingdom if empty.
+	Provider string
+
+	// Timezone is the IANA name of the zone the alert timestamps are rendered in (UTC if empty).
+	Timezone string
+	// TimeFormat is either a Go time layout or one of the names from namedTimeFormats.
+	TimeFormat string
+
+	// ParamsAllowList and ParamsDenyList are comma-separated check_params keys which are
+	// shown (or hidden) when the check type has no dedicated renderer.
+	ParamsAllowList string
+	ParamsDenyList  string
+
+	// PingdomBaseURL is the Pingdom UI the alerts link to (pingdom.DefaultBaseURL if empty).
+	PingdomBaseURL string
+
+	// AuthMethod is the way the webhook calls are authenticated besides the query string seed:
+	// "header", "basic" or "hmac" (see auth.go). The seed is the secret for all of them.
+	AuthMethod string
+	// AuthHeader is the header carrying the seed or the HMAC signature.
+	AuthHeader string
+	// BasicUsername is the username of the HTTP Basic credentials, the seed is the password.
+	BasicUsername string
+	// DisableQuerySeed rejects the seed in the query string, which leaks into the access logs.
+	DisableQuerySeed bool
+
+	// RetiredSeeds are the previous seeds which keep working until they expire.
+	RetiredSeeds []retiredSeed
+	// SeedGracePeriodHours is how long the regenerated seed keeps working (used by the webapp).
+	SeedGracePeriodHours int
+
+	// AllowedCIDRs is the comma-separated list of CIDRs (or addresses) the webhook calls may come from.
+	AllowedCIDRs string
+	// AllowPingdomProbes allows the calls from the Pingdom probes, fetched with the Token (see allowlist.go).
+	AllowPingdomProbes bool
+	// TrustedProxies is the comma-separated list of CIDRs of the reverse proxies whose
+	// X-Forwarded-For header is honoured.
+	TrustedProxies string
+
+	// RateLimitPerMinute and RateLimitBurst are the token bucket limits of the alerts the hook
+	// accepts (see ratelimit.go for the defaults).
+	RateLimitPerMinute int
+	RateLimitBurst     int
+
+	// HistoryRetentionDays is how long the received alerts are kept for /pingdom history
+	// (defaultHistoryRetentionDays if 0).
+	HistoryRetentionDays int
+
+	// HeartbeatIntervalHours is how long the hook may stay silent before the watchdog warns about
+	// it (see watchdog.go), the watchdog is off if 0.
+	HeartbeatIntervalHours int
 }
 
 func (ac *pingdomHookConfig) IsValid() error {
-	if ac.Team == "" {
+	if ac.GetTarget() == targetChannel && ac.Team == "" {
 		return errors.New("must set a Team")
 	}
 
@@ -40,6 +109,10 @@ func (ac *pingdomHookConfig) IsValid() error {
 		return errors.New("must set a Channel")
 	}
 
+	if err := ac.isValidTarget(); err != nil {
+		return err
+	}
+
 	//if ac.Token == "" {
 	//	return errors.New("must set a Token")
 	//}
@@ -48,16 +121,104 @@ func (ac *pingdomHookConfig) IsValid() error {
 		return errors.New("must set a Seed")
 	}
 
+	if _, ok := alertDecoders[ac.GetProvider()]; !ok {
+		return fmt.Errorf("unknown Provider %q", ac.Provider)
+	}
+
+	if err := isValidAuthMethod(ac.AuthMethod); err != nil {
+		return err
+	}
+
+	if ac.AuthMethod == authMethodBasic && ac.BasicUsername == "" {
+		return errors.New("must set a Basic Username")
+	}
+
+	if ac.AuthMethod == authMethodQuery && ac.DisableQuerySeed {
+		return errors.New("must set an Auth Method when the query string seed is disabled")
+	}
+
+	if ac.SeedGracePeriodHours < 0 {
+		return errors.New("Seed Grace Period can not be negative")
+	}
+
+	if ac.HistoryRetentionDays < 0 {
+		return errors.New("History Retention can not be negative")
+	}
+
+	if ac.HeartbeatIntervalHours < 0 {
+		return errors.New("Heartbeat Interval can not be negative")
+	}
+
+	if ac.RateLimitPerMinute < 0 || ac.RateLimitBurst < 0 {
+		return errors.New("Rate Limit can not be negative")
+	}
+
+	if _, err := parseCIDRs(ac.AllowedCIDRs); err != nil {
+		return fmt.Errorf("invalid Allowed CIDRs: %w", err)
+	}
+
+	if _, err := parseCIDRs(ac.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid Trusted Proxies: %w", err)
+	}
+
+	if ac.AllowPingdomProbes && ac.Token == "" {
+		return errors.New("must set a Token to allow the Pingdom probes")
+	}
+
+	if ac.PingdomBaseURL != "" {
+		u, err := url.Parse(ac.PingdomBaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid Pingdom Base URL %q", ac.PingdomBaseURL)
+		}
+	}
+
 	return nil
 }
 
-// Clone shallow copies the configuration. Your implementation may require a deep copy if
-// your configuration has reference types.
+// GetProvider returns the provider the hook receives the alerts from.
+func (ac *pingdomHookConfig) GetProvider() string {
+	if ac.Provider == "" {
+		return uptime.ProviderPingdom
+	}
+	return ac.Provider
+}
+
+// GetLocation returns the time zone the alerts of the hook should be rendered in. The invalid
+// Timezone does not make the hook invalid: the error is logged on the configuration change and the
+// callers fall back to UTC.
+func (ac *pingdomHookConfig) GetLocation() (*time.Location, error) {
+	if ac.Timezone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(ac.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Timezone %q: %w", ac.Timezone, err)
+	}
+	return loc, nil
+}
+
+// GetParamsFilter returns the filter for the check_params keys rendered by the generic renderer.
+func (ac *pingdomHookConfig) GetParamsFilter() paramsFilter {
+	return newParamsFilter(ac.ParamsAllowList, ac.ParamsDenyList)
+}
+
+// GetTimeLayout returns the Go time layout the alerts of the hook should be rendered with.
+func (ac *pingdomHookConfig) GetTimeLayout() string {
+	return timeLayout(ac.TimeFormat)
+}
+
+// Clone deep copies the configuration.
 func (c *configuration) Clone() *configuration {
-	var clone configuration
+	clone := configuration{
+		PingdomHooksConfigs: make(map[string]pingdomHookConfig, len(c.PingdomHooksConfigs)),
+		MetricsToken:        c.MetricsToken,
+	}
 	for k, v := range c.PingdomHooksConfigs {
+		v.RetiredSeeds = append([]retiredSeed(nil), v.RetiredSeeds...)
 		clone.PingdomHooksConfigs[k] = v
 	}
+	clone.seedIndex = newSeedIndex(clone.PingdomHooksConfigs)
 	return &clone
 }
 
@@ -118,7 +279,11 @@ func (p *Plugin) OnConfigurationChange() error {
 	for id, pingdomHookConfigInstance := range configurationInstance.PingdomHooksConfigs {
 		pingdomHookConfigInstance.ID = id
 		configurationInstance.PingdomHooksConfigs[id] = pingdomHookConfigInstance
+		if _, err := pingdomHookConfigInstance.GetLocation(); err != nil {
+			p.API.LogWarn("The Timezone of the hook is invalid, UTC is used instead", "hook_id", id, "err", err.Error())
+		}
 	}
+	configurationInstance.seedIndex = newSeedIndex(configurationInstance.PingdomHooksConfigs)
 
 	p.setConfiguration(&configurationInstance)
 
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+// maxDeadLettersPerHook limits the dead-letter list of the hook, the oldest entries are dropped.
+const maxDeadLettersPerHook = 100
+
+// deadLetter is the alert which could not be posted, it is kept until it is replayed.
+type deadLetter struct {
+	ID         string       `json:"id"`
+	HookID     string       `json:"hook_id"`
+	Message    uptime.Alert `json:"message"`
+	Reason     string       `json:"reason"`
+	Attempts   int          `json:"attempts"`
+	EnqueuedAt int64        `json:"enqueued_at"`
+	FailedAt   int64        `json:"failed_at"`
+}
+
+func deadLettersKey(hookID string) string {
+	return fmt.Sprintf("dead_letters_%s", hookID)
+}
+
+// updateDeadLetters modifies the dead-letter list of the hook atomically.
+func (p *Plugin) updateDeadLetters(hookID string, update func([]deadLetter) []deadLetter) error {
+	err := p.client.KV.SetAtomicWithRetries(deadLettersKey(hookID), func(oldValue []byte) (interface{}, error) {
+		var letters []deadLetter
+		if len(oldValue) > 0 {
+			if err := json.Unmarshal(oldValue, &letters); err != nil {
+				return nil, err
+			}
+		}
+		return update(letters), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the dead letters: %w", err)
+	}
+	return nil
+}
+
+// addDeadLetter stores the alert which permanently failed to be posted.
+func (p *Plugin) addDeadLetter(item *queuedAlert) error {
+	letter := deadLetter{
+		ID:         model.NewId()[:8],
+		HookID:     item.HookID,
+		Message:    item.Message,
+		Reason:     item.LastError,
+		Attempts:   item.Attempts,
+		EnqueuedAt: item.EnqueuedAt,
+		FailedAt:   time.Now().UnixMilli(),
+	}
+	return p.updateDeadLetters(item.HookID, func(letters []deadLetter) []deadLetter {
+		letters = append(letters, letter)
+		if len(letters) > maxDeadLettersPerHook {
+			letters = letters[len(letters)-maxDeadLettersPerHook:]
+		}
+		return letters
+	})
+}
+
+// getDeadLetters returns the dead letters of the configured hooks, the oldest first.
+func (p *Plugin) getDeadLetters() ([]deadLetter, error) {
+	var all []deadLetter
+	for hookID := range p.getConfiguration().PingdomHooksConfigs {
+		var letters []deadLetter
+		if err := p.client.KV.Get(deadLettersKey(hookID), &letters); err != nil {
+			return nil, fmt.Errorf("failed to get the dead letters: %w", err)
+		}
+		all = append(all, letters...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].FailedAt < all[j].FailedAt
+	})
+	return all, nil
+}
+
+// replayDeadLetter posts the dead letter again. It is removed from the list when it is posted,
+// otherwise the failure reason is updated.
+func (p *Plugin) replayDeadLetter(letter deadLetter) error {
+	config, ok := p.getConfiguration().PingdomHooksConfigs[letter.HookID]
+	if !ok {
+		return fmt.Errorf("the hook %s does not exist anymore", letter.HookID)
+	}
+
+	_, deliveryErr := p.deliverAlert(config, letter.Message)
+	err := p.updateDeadLetters(letter.HookID, func(letters []deadLetter) []deadLetter {
+		var kept []deadLetter
+		for _, l := range letters {
+			if l.ID == letter.ID {
+				if deliveryErr == nil {
+					continue
+				}
+				l.Reason = deliveryErr.Error()
+				l.Attempts++
+				l.FailedAt = time.Now().UnixMilli()
+			}
+			kept = append(kept, l)
+		}
+		return kept
+	})
+	if deliveryErr != nil {
+		return deliveryErr
+	}
+	return err
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+func TestAddDeadLetter(t *testing.T) {
+	p := newTestPlugin(newFakeAPI(), &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{
+		"0": {ID: "0"},
+		"1": {ID: "1"},
+	}})
+
+	for i := 1; i <= maxDeadLettersPerHook+5; i++ {
+		item := &queuedAlert{HookID: "0", Message: uptime.Alert{CheckID: uint64(i)}, Attempts: i, LastError: "unavailable"}
+		if err := p.addDeadLetter(item); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := p.addDeadLetter(&queuedAlert{HookID: "1", Message: uptime.Alert{CheckID: 1000}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var letters []deadLetter
+	if err := p.client.KV.Get(deadLettersKey("0"), &letters); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(letters) != maxDeadLettersPerHook {
+		t.Fatalf("expected %d dead letters, got %d", maxDeadLettersPerHook, len(letters))
+	}
+	if letters[0].Message.CheckID != 6 || letters[len(letters)-1].Message.CheckID != maxDeadLettersPerHook+5 {
+		t.Errorf("expected the oldest dead letters to be dropped, got the checks %d to %d",
+			letters[0].Message.CheckID, letters[len(letters)-1].Message.CheckID)
+	}
+	last := letters[len(letters)-1]
+	if last.ID == "" || last.HookID != "0" || last.Reason != "unavailable" || last.Attempts != maxDeadLettersPerHook+5 || last.FailedAt == 0 {
+		t.Errorf("unexpected dead letter: %+v", last)
+	}
+
+	all, err := p.getDeadLetters()
+	if err != nil || len(all) != maxDeadLettersPerHook+1 {
+		t.Errorf("expected the dead letters of both hooks, got %d, %v", len(all), err)
+	}
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	api := newFakeAPI()
+	p := newTestPlugin(api, &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{
+		"0": {ID: "0", Team: "team", Channel: "alerts"},
+	}})
+	p.channels.set("0", "channel-0", time.Now())
+
+	for _, item := range []*queuedAlert{
+		{HookID: "0", Message: uptime.Alert{CheckID: 1, CheckName: "web", CurrentState: "DOWN"}, Attempts: 12},
+		{HookID: "0", Message: uptime.Alert{CheckID: 2, CheckName: "api", CurrentState: "DOWN"}, Attempts: 12},
+	} {
+		if err := p.addDeadLetter(item); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	letters, err := p.getDeadLetters()
+	if err != nil || len(letters) != 2 {
+		t.Fatalf("expected 2 dead letters, got %d, %v", len(letters), err)
+	}
+
+	// The failed replay keeps the dead letter with the new reason.
+	api.postErr = model.NewAppError("CreatePost", "unavailable", nil, "", http.StatusServiceUnavailable)
+	if err := p.replayDeadLetter(letters[0]); err == nil {
+		t.Fatal("expected the replay to fail")
+	}
+	kept, _ := p.getDeadLetters()
+	var failed *deadLetter
+	for i := range kept {
+		if kept[i].ID == letters[0].ID {
+			failed = &kept[i]
+		}
+	}
+	if len(kept) != 2 || failed == nil || failed.Attempts != 13 || failed.Reason == "" {
+		t.Fatalf("expected the dead letter to be kept with the new attempt, got %+v", kept)
+	}
+
+	// The replayed dead letter is removed.
+	api.postErr = nil
+	p.channels.set("0", "channel-0", time.Now())
+	if err := p.replayDeadLetter(letters[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	kept, _ = p.getDeadLetters()
+	if len(kept) != 1 || kept[0].ID != letters[1].ID || len(api.posts) != 1 {
+		t.Errorf("expected the dead letter to be posted and removed, got %+v, %d posts", kept, len(api.posts))
+	}
+
+	// The dead letter of the deleted hook can not be replayed.
+	if err := p.replayDeadLetter(deadLetter{ID: "deleted", HookID: "1"}); err == nil {
+		t.Error("expected the replay of the deleted hook to fail")
+	}
+}
+
+func TestHandleReplay(t *testing.T) {
+	for name, tc := range map[string]struct {
+		admin         bool
+		command       string
+		postErr       *model.AppError
+		expected      string
+		expectedPosts int
+		expectedKept  int
+	}{
+		"not an admin": {
+			command:      "/pingdom replay all",
+			expected:     "Only the system admins can replay the dead letters.",
+			expectedKept: 2,
+		},
+		"missing ID": {
+			admin:        true,
+			command:      "/pingdom replay",
+			expected:     "Please specify the ID of the dead letter or `all`",
+			expectedKept: 2,
+		},
+		"not found": {
+			admin:        true,
+			command:      "/pingdom replay missing",
+			expected:     "The dead letter `missing` is not found.",
+			expectedKept: 2,
+		},
+		"by ID": {
+			admin:         true,
+			command:       "/pingdom replay first",
+			expected:      "Replayed 1 dead letter(s).",
+			expectedPosts: 1,
+			expectedKept:  1,
+		},
+		"all": {
+			admin:         true,
+			command:       "/pingdom replay all",
+			expected:      "Replayed 2 dead letter(s).",
+			expectedPosts: 2,
+		},
+		"failed again": {
+			admin:        true,
+			command:      "/pingdom replay all",
+			postErr:      model.NewAppError("CreatePost", "unavailable", nil, "", http.StatusServiceUnavailable),
+			expected:     "Replayed 0 dead letter(s). 2 dead letter(s) failed again:\n- `first`: ",
+			expectedKept: 2,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			api := newFakeAPI()
+			api.admins["admin"] = tc.admin
+			api.postErr = tc.postErr
+			p := newTestPlugin(api, &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{
+				"0": {ID: "0", Team: "team", Channel: "alerts"},
+			}})
+			p.channels.set("0", "channel-0", time.Now())
+			letters := []deadLetter{
+				{ID: "first", HookID: "0", Message: uptime.Alert{CheckID: 1, CurrentState: "DOWN"}, FailedAt: 1},
+				{ID: "second", HookID: "0", Message: uptime.Alert{CheckID: 2, CurrentState: "DOWN"}, FailedAt: 2},
+			}
+			if _, err := p.client.KV.Set(deadLettersKey("0"), letters); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			msg := p.executeCommand(&model.CommandArgs{UserId: "admin", Command: tc.command})
+			if !strings.HasPrefix(msg, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, msg)
+			}
+			kept, err := p.getDeadLetters()
+			if err != nil || len(kept) != tc.expectedKept || len(api.posts) != tc.expectedPosts {
+				t.Errorf("expected %d dead letters and %d posts, got %d, %d posts, %v", tc.expectedKept, tc.expectedPosts, len(kept), len(api.posts), err)
+			}
+		})
+	}
+}
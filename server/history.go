@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+const (
+	// defaultHistoryRetentionDays is used when the hook does not define its own HistoryRetentionDays.
+	defaultHistoryRetentionDays = 30
+	// maxHistoryEntriesPerCheck limits the history of the flapping check.
+	maxHistoryEntriesPerCheck = 1000
+	// defaultHistorySince is the period /pingdom history shows when --since is not given.
+	defaultHistorySince = 7 * 24 * time.Hour
+	// historyPruneInterval is how often the history of the quiet checks and of the deleted hooks is pruned.
+	historyPruneInterval = 6 * time.Hour
+	// historyChecksKeyPrefix starts the keys of the checks which have the history. The entries are
+	// kept under historyLogKeyPrefix, the namespaces are distinct, so the hook IDs can not collide.
+	historyChecksKeyPrefix = "history_idx_"
+	// historyLogKeyPrefix starts the keys of the history entries of the checks.
+	historyLogKeyPrefix = "history_log_"
+)
+
+// historyEntry is the received alert as it is kept in the history of the check.
+type historyEntry struct {
+	ReceivedAt      int64  `json:"received_at"`
+	ChangedAt       int64  `json:"changed_at"`
+	PreviousState   string `json:"previous_state"`
+	CurrentState    string `json:"current_state"`
+	Description     string `json:"description,omitempty"`
+	FirstProbe      string `json:"first_probe,omitempty"`
+	SecondProbe     string `json:"second_probe,omitempty"`
+	ImportanceLevel string `json:"importance_level,omitempty"`
+}
+
+// historyCheck is the check of the hook which has the history, the checks are looked up by name.
+type historyCheck struct {
+	CheckID   uint64 `json:"check_id"`
+	CheckName string `json:"check_name"`
+}
+
+// GetHistoryRetention returns how long the alerts of the hook are kept in the history.
+func (ac *pingdomHookConfig) GetHistoryRetention() time.Duration {
+	days := ac.HistoryRetentionDays
+	if days <= 0 {
+		days = defaultHistoryRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func historyKey(hookID string, checkID uint64) string {
+	return fmt.Sprintf("%s%s_%d", historyLogKeyPrefix, hookID, checkID)
+}
+
+func historyChecksKey(hookID string) string {
+	return historyChecksKeyPrefix + hookID
+}
+
+// newHistoryEntry converts the received alert into the history entry.
+func newHistoryEntry(message uptime.Alert, receivedAt time.Time) historyEntry {
+	entry := historyEntry{
+		ReceivedAt:      receivedAt.UnixMilli(),
+		ChangedAt:       message.ChangedAt().UnixMilli(),
+		PreviousState:   message.PreviousState,
+		CurrentState:    message.CurrentState,
+		Description:     message.Description,
+		ImportanceLevel: message.ImportanceLevel,
+	}
+	if message.StateChangedTimestamp == 0 {
+		entry.ChangedAt = entry.ReceivedAt
+	}
+	if message.FirstProbe != nil {
+		entry.FirstProbe = message.FirstProbe.Location
+	}
+	if message.SecondProbe != nil {
+		entry.SecondProbe = message.SecondProbe.Location
+	}
+	return entry
+}
+
+// pruneHistory drops the entries older than the retention and the oldest entries over the limit.
+func pruneHistory(entries []historyEntry, retention time.Duration, now time.Time) []historyEntry {
+	cutoff := now.Add(-retention).UnixMilli()
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.ReceivedAt >= cutoff {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) > maxHistoryEntriesPerCheck {
+		kept = kept[len(kept)-maxHistoryEntriesPerCheck:]
+	}
+	return kept
+}
+
+// recordHistory appends the received alert to the history of the check.
+func (p *Plugin) recordHistory(config pingdomHookConfig, message uptime.Alert) error {
+	now := time.Now()
+	entry := newHistoryEntry(message, now)
+
+	err := p.client.KV.SetAtomicWithRetries(historyKey(config.ID, message.CheckID), func(oldValue []byte) (interface{}, error) {
+		var entries []historyEntry
+		if len(oldValue) > 0 {
+			if err := json.Unmarshal(oldValue, &entries); err != nil {
+				return nil, err
+			}
+		}
+		return pruneHistory(append(entries, entry), config.GetHistoryRetention(), now), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record the history: %w", err)
+	}
+
+	err = p.client.KV.SetAtomicWithRetries(historyChecksKey(config.ID), func(oldValue []byte) (interface{}, error) {
+		checks := make(map[string]historyCheck)
+		if len(oldValue) > 0 {
+			if err := json.Unmarshal(oldValue, &checks); err != nil {
+				return nil, err
+			}
+		}
+		checks[strconv.FormatUint(message.CheckID, 10)] = historyCheck{CheckID: message.CheckID, CheckName: message.CheckName}
+		return checks, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record the history check: %w", err)
+	}
+	return nil
+}
+
+// pruneAllHistory is the cluster job applying the retention to the checks which have received no alert
+// since, recordHistory prunes the history of the alerting checks only. The history of the deleted hooks
+// is dropped.
+func (p *Plugin) pruneAllHistory() {
+	keys, err := p.listKeys(historyChecksKeyPrefix)
+	if err != nil {
+		p.API.LogWarn("Failed to list the hooks with the history", "err", err.Error())
+		return
+	}
+
+	hooks := p.getConfiguration().PingdomHooksConfigs
+	for _, key := range keys {
+		hookID := strings.TrimPrefix(key, historyChecksKeyPrefix)
+		var retention time.Duration
+		if config, ok := hooks[hookID]; ok {
+			retention = config.GetHistoryRetention()
+		}
+		if err := p.pruneHookHistory(hookID, retention, time.Now()); err != nil {
+			p.API.LogWarn("Failed to prune the history", "hook_id", hookID, "err", err.Error())
+		}
+	}
+}
+
+// pruneHookHistory drops the history entries of the hook older than the retention, the checks left
+// without the history are removed from the index. The zero retention drops the whole history.
+func (p *Plugin) pruneHookHistory(hookID string, retention time.Duration, now time.Time) error {
+	checks := make(map[string]historyCheck)
+	if err := p.client.KV.Get(historyChecksKey(hookID), &checks); err != nil {
+		return fmt.Errorf("failed to get the history checks: %w", err)
+	}
+
+	emptied := make(map[string]bool)
+	for id, check := range checks {
+		empty := false
+		err := p.client.KV.SetAtomicWithRetries(historyKey(hookID, check.CheckID), func(oldValue []byte) (interface{}, error) {
+			var entries []historyEntry
+			if len(oldValue) > 0 {
+				if err := json.Unmarshal(oldValue, &entries); err != nil {
+					return nil, err
+				}
+			}
+			if retention > 0 {
+				entries = pruneHistory(entries, retention, now)
+			}
+			if retention == 0 || len(entries) == 0 {
+				empty = true
+				return nil, nil
+			}
+			return entries, nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to prune the history of the check %d: %w", check.CheckID, err)
+		}
+		if empty {
+			emptied[id] = true
+		}
+	}
+	if len(emptied) == 0 {
+		return nil
+	}
+
+	err := p.client.KV.SetAtomicWithRetries(historyChecksKey(hookID), func(oldValue []byte) (interface{}, error) {
+		checks := make(map[string]historyCheck)
+		if len(oldValue) > 0 {
+			if err := json.Unmarshal(oldValue, &checks); err != nil {
+				return nil, err
+			}
+		}
+		for id, check := range checks {
+			if !emptied[id] {
+				continue
+			}
+			// The check which has received the alert meanwhile stays.
+			var entries []historyEntry
+			if err := p.client.KV.Get(historyKey(hookID, check.CheckID), &entries); err != nil {
+				return nil, err
+			}
+			if len(entries) == 0 {
+				delete(checks, id)
+			}
+		}
+		if len(checks) == 0 {
+			return nil, nil
+		}
+		return checks, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to prune the history checks: %w", err)
+	}
+	return nil
+}
+
+// findHistoryChecks returns the checks of the hook matching the check ID or the (case-insensitive) name.
+func (p *Plugin) findHistoryChecks(hookID, check string) ([]historyCheck, error) {
+	checks := make(map[string]historyCheck)
+	if err := p.client.KV.Get(historyChecksKey(hookID), &checks); err != nil {
+		return nil, fmt.Errorf("failed to get the history checks: %w", err)
+	}
+
+	var found []historyCheck
+	for id, c := range checks {
+		if id == check || strings.EqualFold(c.CheckName, check) {
+			found = append(found, c)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].CheckID < found[j].CheckID
+	})
+	return found, nil
+}
+
+// getHistory returns the history entries of the check received since the given time, the oldest first.
+func (p *Plugin) getHistory(hookID string, checkID uint64, since time.Time) ([]historyEntry, error) {
+	var entries []historyEntry
+	if err := p.client.KV.Get(historyKey(hookID, checkID), &entries); err != nil {
+		return nil, fmt.Errorf("failed to get the history: %w", err)
+	}
+
+	var found []historyEntry
+	for _, entry := range entries {
+		if entry.ReceivedAt >= since.UnixMilli() {
+			found = append(found, entry)
+		}
+	}
+	return found, nil
+}
+
+// parseSince parses the period of /pingdom history: a Go duration (e.g. 36h) or the number of days (e.g. 7d).
+func parseSince(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid period %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid period %q", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	for name, tc := range map[string]struct {
+		value       string
+		expected    time.Duration
+		expectedErr bool
+	}{
+		"days":          {value: "7d", expected: 7 * 24 * time.Hour},
+		"hours":         {value: "36h", expected: 36 * time.Hour},
+		"minutes":       {value: "90m", expected: 90 * time.Minute},
+		"zero days":     {value: "0d", expectedErr: true},
+		"negative":      {value: "-1h", expectedErr: true},
+		"invalid days":  {value: "xd", expectedErr: true},
+		"invalid value": {value: "week", expectedErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			d, err := parseSince(tc.value)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if d != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, d)
+			}
+		})
+	}
+}
+
+func TestPruneHistory(t *testing.T) {
+	now := time.Now()
+	entries := []historyEntry{
+		{ReceivedAt: now.Add(-48 * time.Hour).UnixMilli(), CurrentState: "DOWN"},
+		{ReceivedAt: now.Add(-12 * time.Hour).UnixMilli(), CurrentState: "UP"},
+		{ReceivedAt: now.UnixMilli(), CurrentState: "DOWN"},
+	}
+
+	kept := pruneHistory(entries, 24*time.Hour, now)
+	if len(kept) != 2 || kept[0].CurrentState != "UP" {
+		t.Errorf("expected the entries within the retention to be kept, got %v", kept)
+	}
+
+	many := make([]historyEntry, maxHistoryEntriesPerCheck+10)
+	for i := range many {
+		many[i] = historyEntry{ReceivedAt: now.UnixMilli() + int64(i)}
+	}
+	kept = pruneHistory(many, 24*time.Hour, now)
+	if len(kept) != maxHistoryEntriesPerCheck || kept[0].ReceivedAt != now.UnixMilli()+10 {
+		t.Errorf("expected the latest %d entries to be kept, got %d", maxHistoryEntriesPerCheck, len(kept))
+	}
+}
+
+func TestHistoryKeys(t *testing.T) {
+	if historyKey("checks", 5) == historyChecksKey("5") {
+		t.Errorf("expected the history of the hook \"checks\" not to collide with the checks of the hook \"5\"")
+	}
+	if strings.HasPrefix(historyKey("0", 1), historyChecksKeyPrefix) {
+		t.Errorf("expected the history entries to be out of the checks namespace, got %s", historyKey("0", 1))
+	}
+}
+
+func TestPruneAllHistory(t *testing.T) {
+	api := newFakeAPI()
+	p := newTestPlugin(api, &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{
+		"0": {ID: "0", HistoryRetentionDays: 30},
+	}})
+
+	now := time.Now()
+	old := historyEntry{ReceivedAt: now.Add(-40 * 24 * time.Hour).UnixMilli(), CurrentState: "DOWN"}
+	recent := historyEntry{ReceivedAt: now.Add(-time.Hour).UnixMilli(), CurrentState: "UP"}
+	for key, value := range map[string]interface{}{
+		historyKey("0", 1):    []historyEntry{old},
+		historyKey("0", 2):    []historyEntry{old, recent},
+		historyChecksKey("0"): map[string]historyCheck{"1": {CheckID: 1, CheckName: "quiet"}, "2": {CheckID: 2, CheckName: "alerting"}},
+		historyKey("1", 3):    []historyEntry{recent},
+		historyChecksKey("1"): map[string]historyCheck{"3": {CheckID: 3, CheckName: "deleted hook"}},
+		checkStateKey("0", 1): checkState{HookID: "0", CheckID: 1},
+		deliveryQueuePrefix:   "unrelated",
+	} {
+		if _, err := p.client.KV.Set(key, value); err != nil {
+			t.Fatalf("failed to set %s: %v", key, err)
+		}
+	}
+
+	p.pruneAllHistory()
+
+	var entries []historyEntry
+	if err := p.client.KV.Get(historyKey("0", 1), &entries); err != nil || len(entries) != 0 {
+		t.Errorf("expected the history of the quiet check to be dropped, got %v, %v", entries, err)
+	}
+	if err := p.client.KV.Get(historyKey("0", 2), &entries); err != nil || len(entries) != 1 || entries[0] != recent {
+		t.Errorf("expected the recent entry to be kept, got %v, %v", entries, err)
+	}
+	checks := make(map[string]historyCheck)
+	if err := p.client.KV.Get(historyChecksKey("0"), &checks); err != nil || len(checks) != 1 || checks["2"].CheckID != 2 {
+		t.Errorf("expected the alerting check to stay in the index, got %v, %v", checks, err)
+	}
+
+	for _, key := range []string{historyKey("1", 3), historyChecksKey("1")} {
+		var value []byte
+		if err := p.client.KV.Get(key, &value); err != nil || value != nil {
+			t.Errorf("expected %s of the deleted hook to be dropped, got %s, %v", key, value, err)
+		}
+	}
+	var state checkState
+	if err := p.client.KV.Get(checkStateKey("0", 1), &state); err != nil || state.CheckID != 1 {
+		t.Errorf("expected the other keys to stay, got %+v, %v", state, err)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+// The reasons the webhook calls are rejected with, they label pingdom_webhooks_rejected_total.
+const (
+	rejectUnknownHook      = "unknown_hook"
+	rejectUnauthorized     = "unauthorized"
+	rejectDisabled         = "disabled"
+	rejectSourceNotAllowed = "source_not_allowed"
+	rejectRateLimited      = "rate_limited"
+	rejectBodyTooLarge     = "body_too_large"
+	rejectInvalidPayload   = "invalid_payload"
+	rejectDuplicate        = "duplicate"
+	rejectQueueFailed      = "queue_failed"
+)
+
+// pluginMetrics is the instrumentation of the alerting pipeline. The counters are kept in memory of
+// the node, so every node of the cluster reports its own calls. The DOWN checks are counted from the
+// KV store when they are scraped, so every node reports the same value.
+type pluginMetrics struct {
+	registry *prometheus.Registry
+
+	webhooksReceived *prometheus.CounterVec
+	webhooksRejected *prometheus.CounterVec
+	processing       *prometheus.HistogramVec
+	postFailures     *prometheus.CounterVec
+	apiCalls         *prometheus.CounterVec
+	apiErrors        *prometheus.CounterVec
+	downChecks       *downChecksCollector
+}
+
+func newPluginMetrics(countDownChecks func() (map[string]int, error)) *pluginMetrics {
+	m := &pluginMetrics{
+		registry: prometheus.NewRegistry(),
+		webhooksReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pingdom_webhooks_received_total",
+			Help: "The alerts accepted by the webhook.",
+		}, []string{"hook"}),
+		webhooksRejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pingdom_webhooks_rejected_total",
+			Help: "The webhook calls which had not been accepted.",
+		}, []string{"hook", "reason"}),
+		processing: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "pingdom_webhook_processing_seconds",
+			Help:    "The time the webhook call takes to process.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"hook"}),
+		postFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pingdom_post_failures_total",
+			Help: "The alert posts which failed to be created.",
+		}, []string{"hook"}),
+		apiCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pingdom_api_calls_total",
+			Help: "The calls to the Pingdom API.",
+		}, []string{"endpoint"}),
+		apiErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pingdom_api_errors_total",
+			Help: "The failed calls to the Pingdom API.",
+		}, []string{"endpoint"}),
+		downChecks: &downChecksCollector{
+			desc: prometheus.NewDesc("pingdom_down_checks", "The checks which are currently DOWN or FAILING.",
+				[]string{"hook"}, nil),
+			count: countDownChecks,
+		},
+	}
+	m.registry.MustRegister(m.webhooksReceived, m.webhooksRejected, m.processing, m.postFailures,
+		m.apiCalls, m.apiErrors, m.downChecks)
+	return m
+}
+
+// downChecksCollector reports the DOWN (and FAILING) checks of every hook from the check states.
+type downChecksCollector struct {
+	desc  *prometheus.Desc
+	count func() (map[string]int, error)
+}
+
+func (c *downChecksCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *downChecksCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.count()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for hookID, down := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(down), hookID)
+	}
+}
+
+// pluginMetricsOnce guards the lazy creation of Plugin.metrics, the plugin may serve the calls
+// before OnActivate.
+type pluginMetricsOnce struct {
+	once    sync.Once
+	metrics *pluginMetrics
+}
+
+func (p *Plugin) getMetrics() *pluginMetrics {
+	p.metrics.once.Do(func() {
+		p.metrics.metrics = newPluginMetrics(p.countDownChecks)
+	})
+	return p.metrics.metrics
+}
+
+// rejectWebhook responds to the webhook call with the error and counts the rejection.
+func (p *Plugin) rejectWebhook(w http.ResponseWriter, hookID, reason, message string, code int) {
+	p.getMetrics().webhooksRejected.WithLabelValues(hookID, reason).Inc()
+	p.recordAlertRejected(hookID, message)
+	http.Error(w, message, code)
+}
+
+// newPingdomClient creates the Pingdom API client whose calls are counted.
+func (p *Plugin) newPingdomClient(token string) *pingdom.Client {
+	client := pingdom.NewClient(token)
+	m := p.getMetrics()
+	client.OnCall = func(endpoint string, err error) {
+		m.apiCalls.WithLabelValues(endpoint).Inc()
+		if err != nil {
+			m.apiErrors.WithLabelValues(endpoint).Inc()
+		}
+	}
+	return client
+}
+
+// isMetricsAllowed tells if the scraper presented the metrics token or the user is a system admin.
+func (p *Plugin) isMetricsAllowed(r *http.Request) bool {
+	if token := p.getConfiguration().MetricsToken; token != "" {
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+			return true
+		}
+	}
+
+	userID := r.Header.Get("Mattermost-User-ID")
+	return userID != "" && p.API.HasPermissionTo(userID, model.PermissionManageSystem)
+}
+
+// serveMetrics renders the metrics in the Prometheus text exposition format.
+func (p *Plugin) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if !p.isMetricsAllowed(r) {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	promhttp.HandlerFor(p.getMetrics().registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// countDownChecks counts the DOWN and FAILING checks of every configured hook from the stored check
+// states, the check states of every hook are listed at once.
+func (p *Plugin) countDownChecks() (map[string]int, error) {
+	keys, err := p.listKeys(checkStateKeysPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the check states: %w", err)
+	}
+
+	hooks := p.getConfiguration().PingdomHooksConfigs
+	counts := make(map[string]int, len(hooks))
+	for hookID := range hooks {
+		counts[hookID] = 0
+	}
+	for _, key := range keys {
+		var state *checkState
+		if err := p.client.KV.Get(key, &state); err != nil {
+			return nil, fmt.Errorf("failed to get the check state: %w", err)
+		}
+		if state == nil {
+			continue
+		}
+		if _, ok := counts[state.HookID]; ok && (state.State == uptime.StateDown || state.State == uptime.StateFailing) {
+			counts[state.HookID]++
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+)
+
+// paramsFilter decides which check_params keys are rendered by the generic renderer.
+type paramsFilter struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+func newParamsFilter(allowList, denyList string) paramsFilter {
+	return paramsFilter{
+		allow: splitList(allowList),
+		deny:  splitList(denyList),
+	}
+}
+
+// splitList converts the comma-separated list into a set of the lower-cased items.
+func splitList(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
+
+// Allowed reports whether the key should be rendered.
+func (f paramsFilter) Allowed(key string) bool {
+	key = strings.ToLower(key)
+	if f.deny[key] {
+		return false
+	}
+	return len(f.allow) == 0 || f.allow[key]
+}
+
+// renderCheckParams renders every allowed key of the check_params in a stable (sorted) order.
+func renderCheckParams(params pingdom.KV, filter paramsFilter) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if filter.Allowed(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		writeParam(&sb, 0, key, params[key])
+	}
+	return sb.String()
+}
+
+func writeParam(sb *strings.Builder, depth int, key string, value interface{}) {
+	indent := strings.Repeat("  ", depth)
+	prefix := ""
+	if depth > 0 {
+		prefix = "- "
+	}
+
+	if _, ok := value.(string); ok && isSensitiveParam(key) {
+		value = redactedValue
+	}
+
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		fmt.Fprintf(sb, "%s%s**%s**: %s\n", indent, prefix, humanizeParamKey(key), formatParamValue(value))
+		return
+	}
+
+	fmt.Fprintf(sb, "%s%s**%s**:\n", indent, prefix, humanizeParamKey(key))
+	if len(nested) == 0 {
+		fmt.Fprintf(sb, "%s  - n/a\n", indent)
+		return
+	}
+
+	keys := make([]string, 0, len(nested))
+	for k := range nested {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeParam(sb, depth+1, k, nested[k])
+	}
+}
+
+// humanizeParamKey converts the keys like "verify_certificate" into "Verify Certificate".
+func humanizeParamKey(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return r == '_' || r == '-'
+	})
+	for i, word := range words {
+		r, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToUpper(r)) + word[size:]
+	}
+	if len(words) == 0 {
+		return escapeMarkdown(key)
+	}
+	return escapeMarkdown(strings.Join(words, " "))
+}
+
+// formatParamValue renders the scalar (or list) value of the check_params.
+func formatParamValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "n/a"
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case string:
+		if v == "" {
+			return "n/a"
+		}
+		if isURL(v) {
+			return markdownLink(v, v)
+		}
+		return escapeMarkdown(v)
+	case []interface{}:
+		if len(v) == 0 {
+			return "n/a"
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatParamValue(item)
+		}
+		return strings.Join(items, ", ")
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return fmt.Sprintf("`%s`", escapeCode(string(b)))
+	}
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// naString renders the optional value of the typed check parameters, "n/a" is used for the absent ones.
+func naString(v *string) string {
+	if v == nil || *v == "" {
+		return "n/a"
+	}
+	return escapeMarkdown(*v)
+}
+
+// naCode renders the optional value as the inline code.
+func naCode(v *string) string {
+	if v == nil || *v == "" {
+		return "n/a"
+	}
+	return fmt.Sprintf("`%s`", escapeCode(*v))
+}
+
+func naBool(v *bool) string {
+	if v == nil {
+		return "n/a"
+	}
+	return formatParamValue(*v)
+}
+
+func naNumber(v *pingdom.Number) string {
+	if v == nil || *v == 0 {
+		return "n/a"
+	}
+	return strconv.Itoa(int(*v))
+}
+
+// naHeaders renders the request headers of the HTTP check in a stable order.
+func naHeaders(header *string, headers map[string]string) string {
+	var wrapped []string
+	if header != nil && *header != "" {
+		wrapped = append(wrapped, fmt.Sprintf("`%s`", escapeCode(redactHeaderLine(*header))))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := headers[name]
+		if isSensitiveParam(name) {
+			value = redactedValue
+		}
+		wrapped = append(wrapped, fmt.Sprintf("`%s: %s`", escapeCode(name), escapeCode(value)))
+	}
+
+	if len(wrapped) == 0 {
+		return "n/a"
+	}
+	return strings.Join(wrapped, ", ")
+}
+
+// redactedValue replaces the secrets of the check parameters in the post.
+const redactedValue = "[redacted]"
+
+// sensitiveParamWords are the parts of the header, form field and JSON key names whose values are
+// the credentials, e.g. Authorization, X-Api-Key, access_token or password.
+var sensitiveParamWords = []string{"auth", "cookie", "token", "secret", "password", "passwd", "apikey", "accesskey", "privatekey", "session", "credential", "signature"}
+
+// isSensitiveParam reports whether the value of the header (or of the field) is a credential.
+func isSensitiveParam(name string) bool {
+	name = strings.NewReplacer("-", "", "_", "", " ", "").Replace(strings.ToLower(name))
+	for _, word := range sensitiveParamWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHeaderLine redacts the value of the "Name: value" header of the HTTP check.
+func redactHeaderLine(header string) string {
+	name, _, found := strings.Cut(header, ":")
+	if !found || !isSensitiveParam(name) {
+		return header
+	}
+	return name + ": " + redactedValue
+}
+
+// redactPostData redacts the credentials of the post data of the HTTP check: the sensitive fields of
+// the JSON object or of the form are replaced, the other bodies are dropped when they mention one.
+func redactPostData(data *string) *string {
+	if data == nil || *data == "" {
+		return data
+	}
+	redacted := redactBody(*data)
+	return &redacted
+}
+
+func redactBody(data string) string {
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &object); err == nil {
+		redactJSON(object)
+		b, err := json.Marshal(object)
+		if err != nil {
+			return redactedValue
+		}
+		return string(b)
+	}
+
+	if strings.Contains(data, "=") {
+		fields := strings.Split(data, "&")
+		for i, field := range fields {
+			rawName, _, _ := strings.Cut(field, "=")
+			name, err := url.QueryUnescape(rawName)
+			if err != nil {
+				name = rawName
+			}
+			if isSensitiveParam(name) {
+				fields[i] = rawName + "=" + redactedValue
+			}
+		}
+		return strings.Join(fields, "&")
+	}
+
+	if isSensitiveParam(data) {
+		return redactedValue
+	}
+	return data
+}
+
+// redactJSON replaces the values of the sensitive keys of the JSON object, the nested objects included.
+func redactJSON(object map[string]interface{}) {
+	for key, value := range object {
+		if isSensitiveParam(key) {
+			object[key] = redactedValue
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			redactJSON(v)
+		case []interface{}:
+			for _, item := range v {
+				if nested, ok := item.(map[string]interface{}); ok {
+					redactJSON(nested)
+				}
+			}
+		}
+	}
+}
+
+// firstString returns the first present value.
+func firstString(values ...*string) *string {
+	for _, v := range values {
+		if v != nil && *v != "" {
+			return v
+		}
+	}
+	return nil
+}
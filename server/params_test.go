@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+)
+
+func TestRenderCheckParams(t *testing.T) {
+	params := pingdom.KV{
+		"hostname":           "example.com",
+		"port":               float64(8443),
+		"verify_certificate": true,
+		"ipv6":               false,
+		"url":                "https://example.com/health",
+		"timeout":            nil,
+		"headers": map[string]interface{}{
+			"X-Trace":       "on",
+			"Accept":        "*/*",
+			"Authorization": "Bearer abc",
+		},
+		"regions": []interface{}{"EU", "NA"},
+	}
+
+	for name, tc := range map[string]struct {
+		allow    string
+		deny     string
+		expected string
+	}{
+		"everything": {
+			expected: "**Headers**:\n" +
+				"  - **Accept**: \\*/\\*\n" +
+				"  - **Authorization**: \\[redacted\\]\n" +
+				"  - **X Trace**: on\n" +
+				"**Hostname**: example.com\n" +
+				"**Ipv6**: no\n" +
+				"**Port**: 8443\n" +
+				"**Regions**: EU, NA\n" +
+				"**Timeout**: n/a\n" +
+				"**Url**: [https://example.com/health](https://example.com/health)\n" +
+				"**Verify Certificate**: yes\n",
+		},
+		"allow list": {
+			allow:    "hostname, PORT",
+			expected: "**Hostname**: example.com\n**Port**: 8443\n",
+		},
+		"deny list wins over allow list": {
+			allow:    "hostname,port",
+			deny:     "port",
+			expected: "**Hostname**: example.com\n",
+		},
+		"nothing allowed": {
+			allow:    "unknown",
+			expected: "",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := renderCheckParams(params, newParamsFilter(tc.allow, tc.deny))
+			if got != tc.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestHumanizeParamKey(t *testing.T) {
+	for key, expected := range map[string]string{
+		"verify_certificate": "Verify Certificate",
+		"x-trace":            "X Trace",
+		"ipv6":               "Ipv6",
+		"émetteur_état":      "Émetteur État",
+		"übertragung":        "Übertragung",
+		"日本_check":           "日本 Check",
+		"_leading__double_":  "Leading Double",
+		"__":                 "\\_\\_",
+		"":                   "",
+	} {
+		if actual := humanizeParamKey(key); actual != expected {
+			t.Errorf("expected %q for %q, got %q", expected, key, actual)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package pingdom
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+// ToAlert maps the Pingdom alert into the provider-neutral model.
+func (m *PingdomCheckMessage) ToAlert() uptime.Alert {
+	a := uptime.Alert{
+		Provider:        uptime.ProviderPingdom,
+		CheckID:         m.CheckID,
+		CheckName:       m.CheckName,
+		CheckType:       m.CheckType,
+		CheckParams:     m.CheckParams,
+		Tags:            m.Tags,
+		PreviousState:   m.PreviousState,
+		CurrentState:    m.CurrentState,
+		ImportanceLevel: m.ImportanceLevel,
+		Description:     m.Description,
+		LongDescription: m.LongDescription,
+		IncidentID:      m.IncidentID,
+		MonitoredURL:    m.MonitoredURL(),
+	}
+	if !m.StateChangedTimestamp.IsZero() {
+		a.StateChangedTimestamp = m.StateChangedTimestamp.Unix()
+	}
+	if m.FirstProbe != nil {
+		a.FirstProbe = &uptime.Probe{IP: m.FirstProbe.IP, IPV6: m.FirstProbe.IPV6, Location: m.FirstProbe.Location}
+	}
+	if m.SecondProbe != nil && m.SecondProbe.FirstProbe != nil {
+		a.SecondProbe = &uptime.Probe{IP: m.SecondProbe.IP, IPV6: m.SecondProbe.IPV6, Location: m.SecondProbe.Location}
+	}
+	return a
+}
+
+// DecodeAlert is the uptime.Decoder of the Pingdom webhooks. Both the current and the legacy alerts
+// are accepted, the legacy integrations post the JSON form-encoded in the message field.
+func DecodeAlert(body []byte, contentType string, receivedAt time.Time) (uptime.Alert, error) {
+	if uptime.IsForm(contentType) {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return uptime.Alert{}, err
+		}
+		body = []byte(values.Get("message"))
+	}
+
+	payload, err := uptime.StrictJSON(body)
+	if err != nil {
+		return uptime.Alert{}, err
+	}
+
+	var message PingdomCheckMessage
+	if IsLegacyPayload(payload) {
+		message, err = DecodeLegacyMessage(payload, receivedAt)
+	} else {
+		err = json.Unmarshal(payload, &message)
+	}
+	if err != nil {
+		return uptime.Alert{}, err
+	}
+	return message.ToAlert(), nil
+}
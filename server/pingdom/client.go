@@ -0,0 +1,120 @@
+package pingdom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the Pingdom API the Client talks to.
+// Ref.: https://docs.pingdom.com/api/
+const DefaultAPIURL = "https://api.pingdom.com/api/3.1"
+
+// Client is the minimal Pingdom API client authenticated with the API token of the hook.
+type Client struct {
+	apiURL     string
+	token      string
+	httpClient *http.Client
+
+	// OnCall is called after every API call with the endpoint (the path without the query) and
+	// the error of the call, e.g. to count the calls.
+	OnCall func(endpoint string, err error)
+}
+
+// NewClient creates the client for the Pingdom API token.
+func NewClient(token string) *Client {
+	return &Client{
+		apiURL:     DefaultAPIURL,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Probe is the Pingdom probe server.
+type Probe struct {
+	ID       int    `json:"id"`
+	Country  string `json:"country"`
+	City     string `json:"city"`
+	Name     string `json:"name"`
+	Active   bool   `json:"active"`
+	Hostname string `json:"hostname"`
+	IP       string `json:"ip"`
+	IPV6     string `json:"ipv6"`
+}
+
+// GetProbes returns the active Pingdom probe servers.
+func (c *Client) GetProbes(ctx context.Context) ([]Probe, error) {
+	var response struct {
+		Probes []Probe `json:"probes"`
+	}
+	if err := c.get(ctx, "/probes?onlyactive=true", &response); err != nil {
+		return nil, err
+	}
+	return response.Probes, nil
+}
+
+// The statuses of the Check.
+const (
+	CheckStatusUp          = "up"
+	CheckStatusDown        = "down"
+	CheckStatusUnconfirmed = "unconfirmed_down"
+	CheckStatusUnknown     = "unknown"
+	CheckStatusPaused      = "paused"
+)
+
+// Check is the Pingdom check as the API lists it. The times are Unix seconds.
+type Check struct {
+	ID               uint64 `json:"id"`
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	Hostname         string `json:"hostname"`
+	Status           string `json:"status"`
+	LastTestTime     int64  `json:"lasttesttime"`
+	LastErrorTime    int64  `json:"lasterrortime"`
+	LastResponseTime int64  `json:"lastresponsetime"`
+}
+
+// GetChecks returns the checks of the account.
+func (c *Client) GetChecks(ctx context.Context) ([]Check, error) {
+	var response struct {
+		Checks []Check `json:"checks"`
+	}
+	if err := c.get(ctx, "/checks", &response); err != nil {
+		return nil, err
+	}
+	return response.Checks, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) (err error) {
+	if c.OnCall != nil {
+		endpoint, _, _ := strings.Cut(path, "?")
+		defer func() { c.OnCall(endpoint, err) }()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create the request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call the Pingdom API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("the Pingdom API responded with %d: %s", resp.StatusCode, body)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode the Pingdom API response: %w", err)
+	}
+	return nil
+}
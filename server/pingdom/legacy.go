@@ -0,0 +1,91 @@
+package pingdom
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Actions of the legacy alerts.
+const (
+	LegacyActionAssign = "assign"
+	LegacyActionClose  = "notify_of_close"
+)
+
+// LegacyID holds the numeric ID which the legacy alerts send either as a number or as a string.
+type LegacyID uint64
+
+// UnmarshalJSON accepts both 12345 and "12345".
+func (id *LegacyID) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*id = 0
+		return nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid ID %s: %w", b, err)
+	}
+	*id = LegacyID(v)
+	return nil
+}
+
+// LegacyCheckMessage is the alert of the older Pingdom integrations.
+type LegacyCheckMessage struct {
+	Check       LegacyID `json:"check"`
+	CheckName   string   `json:"checkname"`
+	Host        string   `json:"host"`
+	Action      string   `json:"action"`
+	IncidentID  LegacyID `json:"incidentid"`
+	Description string   `json:"description"`
+}
+
+// IsLegacyPayload tells whether the JSON object is the legacy alert: it has no check_id, but the
+// check or the checkname.
+func IsLegacyPayload(payload []byte) bool {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &keys); err != nil {
+		return false
+	}
+	if _, ok := keys["check_id"]; ok {
+		return false
+	}
+	_, hasCheck := keys["check"]
+	_, hasCheckName := keys["checkname"]
+	return hasCheck || hasCheckName
+}
+
+// Normalize converts the legacy alert into the current model, so both render the same way. The
+// legacy alert has no timestamp, the time it had been received is used instead.
+func (l LegacyCheckMessage) Normalize(receivedAt time.Time) PingdomCheckMessage {
+	current, previous := "DOWN", "UP"
+	if strings.EqualFold(l.Action, LegacyActionClose) || strings.EqualFold(l.Description, "up") {
+		current, previous = "UP", "DOWN"
+	}
+
+	message := PingdomCheckMessage{
+		CheckID:               uint64(l.Check),
+		CheckName:             l.CheckName,
+		PreviousState:         previous,
+		CurrentState:          current,
+		StateChangedTimestamp: UnixTime{Time: receivedAt.Truncate(time.Second)},
+		StateChangedUTCTime:   TimeString{Time: receivedAt.UTC().Truncate(time.Second)},
+		Description:           l.Description,
+		IncidentID:            uint64(l.IncidentID),
+	}
+	if l.Host != "" {
+		message.CheckParams = KV{"hostname": l.Host}
+	}
+	return message
+}
+
+// DecodeLegacyMessage decodes the legacy alert and normalizes it.
+func DecodeLegacyMessage(payload []byte, receivedAt time.Time) (PingdomCheckMessage, error) {
+	var legacy LegacyCheckMessage
+	if err := json.Unmarshal(payload, &legacy); err != nil {
+		return PingdomCheckMessage{}, err
+	}
+	return legacy.Normalize(receivedAt), nil
+}
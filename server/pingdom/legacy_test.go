@@ -0,0 +1,85 @@
+package pingdom
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsLegacyPayload(t *testing.T) {
+	for name, tc := range map[string]struct {
+		payload  string
+		expected bool
+	}{
+		"current":       {payload: `{"check_id": 1, "check_name": "example.com"}`, expected: false},
+		"legacy":        {payload: `{"check": "1", "checkname": "example.com", "action": "assign"}`, expected: true},
+		"legacy name":   {payload: `{"checkname": "example.com"}`, expected: true},
+		"both":          {payload: `{"check_id": 1, "check": "1"}`, expected: false},
+		"empty":         {payload: `{}`, expected: false},
+		"not an object": {payload: `[]`, expected: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := IsLegacyPayload([]byte(tc.payload)); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDecodeLegacyMessage(t *testing.T) {
+	receivedAt := time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		payload          string
+		expectedCheckID  uint64
+		expectedState    string
+		expectedPrevious string
+		expectedIncident uint64
+	}{
+		"down with string IDs": {
+			payload:          `{"check": "803318", "checkname": "example.com", "host": "www.example.com", "action": "assign", "incidentid": "1234", "description": "down"}`,
+			expectedCheckID:  803318,
+			expectedState:    "DOWN",
+			expectedPrevious: "UP",
+			expectedIncident: 1234,
+		},
+		"closed with numeric IDs": {
+			payload:          `{"check": 803318, "checkname": "example.com", "host": "www.example.com", "action": "notify_of_close", "incidentid": 1234, "description": "up"}`,
+			expectedCheckID:  803318,
+			expectedState:    "UP",
+			expectedPrevious: "DOWN",
+			expectedIncident: 1234,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			message, err := DecodeLegacyMessage([]byte(tc.payload), receivedAt)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if message.CheckID != tc.expectedCheckID {
+				t.Errorf("expected check ID %d, got %d", tc.expectedCheckID, message.CheckID)
+			}
+			if message.CheckName != "example.com" {
+				t.Errorf("expected check name example.com, got %s", message.CheckName)
+			}
+			if message.CurrentState != tc.expectedState || message.PreviousState != tc.expectedPrevious {
+				t.Errorf("expected %s -> %s, got %s -> %s", tc.expectedPrevious, tc.expectedState, message.PreviousState, message.CurrentState)
+			}
+			if message.IncidentID != tc.expectedIncident {
+				t.Errorf("expected incident ID %d, got %d", tc.expectedIncident, message.IncidentID)
+			}
+			if !message.StateChangedTimestamp.Equal(receivedAt) {
+				t.Errorf("expected the state change time %v, got %v", receivedAt, message.StateChangedTimestamp.Time)
+			}
+			if message.CheckParams["hostname"] != "www.example.com" {
+				t.Errorf("expected the hostname in the check params, got %v", message.CheckParams)
+			}
+		})
+	}
+}
+
+func TestDecodeLegacyMessageInvalidID(t *testing.T) {
+	if _, err := DecodeLegacyMessage([]byte(`{"check": "abc", "checkname": "example.com"}`), time.Now()); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
@@ -0,0 +1,76 @@
+package pingdom
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultBaseURL is the Pingdom UI used when the hook does not define its own (e.g. for the
+// white-labelled or regional accounts).
+const DefaultBaseURL = "https://my.pingdom.com"
+
+// UptimeReportURL returns the link to the uptime report of the check.
+func UptimeReportURL(baseURL string, checkID uint64) string {
+	return fmt.Sprintf("%s/app/reports/uptime#check=%d", normalizeBaseURL(baseURL), checkID)
+}
+
+// RootCauseURL returns the link to the root cause analysis of the check.
+func RootCauseURL(baseURL string, checkID uint64) string {
+	return fmt.Sprintf("%s/app/reports/rca#check=%d", normalizeBaseURL(baseURL), checkID)
+}
+
+func normalizeBaseURL(baseURL string) string {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL == "" {
+		return DefaultBaseURL
+	}
+	return baseURL
+}
+
+// MonitoredURL returns the URL the check is monitoring, or the empty string for the checks
+// which are not about the URLs (or when the check parameters are malformed).
+func (m *PingdomCheckMessage) MonitoredURL() string {
+	typed, err := m.TypedCheckParams()
+	if err != nil {
+		return ""
+	}
+
+	switch params := typed.(type) {
+	case *HTTPParams:
+		return buildURL(params.FullURL, params.Hostname, params.URL, params.Port, params.Encryption)
+	case *HTTPCustomParams:
+		return buildURL(params.FullURL, params.Hostname, params.URL, params.Port, params.Encryption)
+	case *TransactionParams:
+		return buildURL(params.FullURL, nil, nil, nil, nil)
+	}
+	return ""
+}
+
+// buildURL prefers the full_url, otherwise it assembles the URL from its parts.
+func buildURL(fullURL, hostname, path *string, port *Number, encryption *bool) string {
+	if fullURL != nil && *fullURL != "" {
+		return *fullURL
+	}
+	if hostname == nil || *hostname == "" {
+		return ""
+	}
+
+	scheme, defaultPort := "http", Number(80)
+	if encryption != nil && *encryption {
+		scheme, defaultPort = "https", 443
+	}
+
+	host := *hostname
+	if port != nil && *port != 0 && *port != defaultPort {
+		host = fmt.Sprintf("%s:%d", host, *port)
+	}
+
+	p := ""
+	if path != nil {
+		p = *path
+	}
+	if p != "" && !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, host, p)
+}
@@ -0,0 +1,82 @@
+package pingdom
+
+import "testing"
+
+func TestReportURLs(t *testing.T) {
+	for name, tc := range map[string]struct {
+		baseURL           string
+		expectedUptime    string
+		expectedRootCause string
+	}{
+		"default": {
+			expectedUptime:    "https://my.pingdom.com/app/reports/uptime#check=42",
+			expectedRootCause: "https://my.pingdom.com/app/reports/rca#check=42",
+		},
+		"blank": {
+			baseURL:           "  ",
+			expectedUptime:    "https://my.pingdom.com/app/reports/uptime#check=42",
+			expectedRootCause: "https://my.pingdom.com/app/reports/rca#check=42",
+		},
+		"custom": {
+			baseURL:           "https://pingdom.example.com",
+			expectedUptime:    "https://pingdom.example.com/app/reports/uptime#check=42",
+			expectedRootCause: "https://pingdom.example.com/app/reports/rca#check=42",
+		},
+		"trailing slash": {
+			baseURL:           "https://pingdom.example.com//",
+			expectedUptime:    "https://pingdom.example.com/app/reports/uptime#check=42",
+			expectedRootCause: "https://pingdom.example.com/app/reports/rca#check=42",
+		},
+		"path prefix": {
+			baseURL:           " https://example.com/pingdom/ ",
+			expectedUptime:    "https://example.com/pingdom/app/reports/uptime#check=42",
+			expectedRootCause: "https://example.com/pingdom/app/reports/rca#check=42",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := UptimeReportURL(tc.baseURL, 42); actual != tc.expectedUptime {
+				t.Errorf("expected the uptime report %q, got %q", tc.expectedUptime, actual)
+			}
+			if actual := RootCauseURL(tc.baseURL, 42); actual != tc.expectedRootCause {
+				t.Errorf("expected the root cause analysis %q, got %q", tc.expectedRootCause, actual)
+			}
+		})
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	str := func(s string) *string { return &s }
+	port := func(n int) *Number { p := Number(n); return &p }
+	yes, no := true, false
+
+	for name, tc := range map[string]struct {
+		fullURL    *string
+		hostname   *string
+		path       *string
+		port       *Number
+		encryption *bool
+		expected   string
+	}{
+		"full URL wins": {
+			fullURL:  str("https://example.com/health"),
+			hostname: str("other.example.com"),
+			path:     str("/other"),
+			expected: "https://example.com/health",
+		},
+		"no hostname":    {path: str("/health"), expected: ""},
+		"empty hostname": {hostname: str(""), expected: ""},
+		"http":           {hostname: str("example.com"), expected: "http://example.com"},
+		"https":          {hostname: str("example.com"), encryption: &yes, path: str("/health"), expected: "https://example.com/health"},
+		"default port":   {hostname: str("example.com"), encryption: &yes, port: port(443), expected: "https://example.com"},
+		"custom port":    {hostname: str("example.com"), encryption: &no, port: port(8080), path: str("/"), expected: "http://example.com:8080/"},
+		"https on 80":    {hostname: str("example.com"), encryption: &yes, port: port(80), expected: "https://example.com:80"},
+		"zero port":      {hostname: str("example.com"), port: port(0), expected: "http://example.com"},
+		"relative path":  {hostname: str("example.com"), path: str("health?full=1"), expected: "http://example.com/health?full=1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := buildURL(tc.fullURL, tc.hostname, tc.path, tc.port, tc.encryption); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
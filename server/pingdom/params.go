@@ -0,0 +1,181 @@
+package pingdom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The check types Pingdom sends in the check_type field.
+const (
+	CheckTypeHTTP        = "HTTP"
+	CheckTypeHTTPCustom  = "HTTP_CUSTOM"
+	CheckTypeDNS         = "DNS"
+	CheckTypeTCP         = "PORT_TCP"
+	CheckTypeUDP         = "UDP"
+	CheckTypeIMAP        = "IMAP"
+	CheckTypePOP3        = "POP3"
+	CheckTypeSMTP        = "SMTP"
+	CheckTypePing        = "PING"
+	CheckTypeTransaction = "TRANSACTION"
+)
+
+// Number holds the integer which Pingdom sends either as a number (443 or 443.0) or as a string ("443").
+type Number int
+
+// UnmarshalJSON accepts 443, 443.0 and "443", the empty string is decoded as 0.
+func (n *Number) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(strings.Trim(string(b), `"`))
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v != math.Trunc(v) || v < math.MinInt32 || v > math.MaxInt32 {
+		return fmt.Errorf("invalid number %s", b)
+	}
+	*n = Number(v)
+	return nil
+}
+
+// CommonParams are the check_params which most of the check types have.
+// The absent values are left nil.
+type CommonParams struct {
+	Hostname  *string `json:"hostname"`
+	IPV6      *bool   `json:"ipv6"`
+	BasicAuth *bool   `json:"basic_auth"`
+}
+
+// HTTPParams are the check_params of the HTTP check.
+type HTTPParams struct {
+	CommonParams
+	FullURL           *string           `json:"full_url"`
+	URL               *string           `json:"url"`
+	Port              *Number           `json:"port"`
+	Encryption        *bool             `json:"encryption"`
+	Header            *string           `json:"header"`
+	RequestHeaders    map[string]string `json:"requestheaders"`
+	ShouldContain     *string           `json:"shouldcontain"`
+	ShouldNotContain  *string           `json:"shouldnotcontain"`
+	PostData          *string           `json:"postdata"`
+	VerifyCertificate *bool             `json:"verify_certificate"`
+}
+
+// HTTPCustomParams are the check_params of the HTTP_CUSTOM check.
+type HTTPCustomParams struct {
+	CommonParams
+	FullURL        *string  `json:"full_url"`
+	URL            *string  `json:"url"`
+	Port           *Number  `json:"port"`
+	Encryption     *bool    `json:"encryption"`
+	AdditionalURLs []string `json:"additional_urls"`
+}
+
+// DNSParams are the check_params of the DNS check.
+type DNSParams struct {
+	CommonParams
+	ExpectedIP *string `json:"expected_ip"`
+	Nameserver *string `json:"nameserver"`
+}
+
+// TCPParams are the check_params of the PORT_TCP check.
+type TCPParams struct {
+	CommonParams
+	Port           *Number `json:"port"`
+	StringToSend   *string `json:"stringtosend"`
+	StringToExpect *string `json:"stringtoexpect"`
+}
+
+// UDPParams are the check_params of the UDP check.
+type UDPParams struct {
+	CommonParams
+	Port           *Number `json:"port"`
+	StringToSend   *string `json:"stringtosend"`
+	StringToExpect *string `json:"stringtoexpect"`
+}
+
+// MailParams are the check_params the mail (IMAP, POP3, SMTP) checks have in common.
+type MailParams struct {
+	CommonParams
+	Port           *Number `json:"port"`
+	Encryption     *bool   `json:"encryption"`
+	StringToExpect *string `json:"stringtoexpect"`
+}
+
+// IMAPParams are the check_params of the IMAP check.
+type IMAPParams struct {
+	MailParams
+}
+
+// POP3Params are the check_params of the POP3 check.
+type POP3Params struct {
+	MailParams
+}
+
+// SMTPParams are the check_params of the SMTP check.
+type SMTPParams struct {
+	MailParams
+}
+
+// PingParams are the check_params of the PING check.
+type PingParams struct {
+	CommonParams
+}
+
+// TransactionParams are the check_params of the TRANSACTION check.
+type TransactionParams struct {
+	FullURL    *string `json:"full_url"`
+	URL        *string `json:"url"`
+	Port       *Number `json:"port"`
+	Encryption *bool   `json:"encryption"`
+}
+
+// ErrUnknownCheckType is returned by TypedCheckParams for the check types without a typed model.
+var ErrUnknownCheckType = errors.New("unknown check type")
+
+// TypedCheckParams decodes the check_params into the typed model of the check type, e.g.
+// *HTTPParams for the HTTP check. ErrUnknownCheckType is returned for the other check types.
+func (m *PingdomCheckMessage) TypedCheckParams() (interface{}, error) {
+	return TypedParams(m.CheckType, m.CheckParams)
+}
+
+// TypedParams decodes the check parameters into the typed model of the check type.
+func TypedParams(checkType string, checkParams map[string]interface{}) (interface{}, error) {
+	var params interface{}
+	switch checkType {
+	case CheckTypeHTTP:
+		params = &HTTPParams{}
+	case CheckTypeHTTPCustom:
+		params = &HTTPCustomParams{}
+	case CheckTypeDNS:
+		params = &DNSParams{}
+	case CheckTypeTCP:
+		params = &TCPParams{}
+	case CheckTypeUDP:
+		params = &UDPParams{}
+	case CheckTypeIMAP:
+		params = &IMAPParams{}
+	case CheckTypePOP3:
+		params = &POP3Params{}
+	case CheckTypeSMTP:
+		params = &SMTPParams{}
+	case CheckTypePing:
+		params = &PingParams{}
+	case CheckTypeTransaction:
+		params = &TransactionParams{}
+	default:
+		return nil, ErrUnknownCheckType
+	}
+
+	b, err := json.Marshal(checkParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal check_params: %w", err)
+	}
+	if err := json.Unmarshal(b, params); err != nil {
+		return nil, fmt.Errorf("failed to decode %s check_params: %w", checkType, err)
+	}
+	return params, nil
+}
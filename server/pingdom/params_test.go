@@ -0,0 +1,187 @@
+package pingdom
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTypedCheckParams(t *testing.T) {
+	payload := `{
+		"check_id": 12345,
+		"check_name": "Name of HTTP check",
+		"check_type": "HTTP",
+		"check_params": {
+			"basic_auth": false,
+			"encryption": true,
+			"full_url": "https://www.example.com/path",
+			"header": "User-Agent:Pingdom.com_bot",
+			"hostname": "www.example.com",
+			"ipv6": false,
+			"port": 443,
+			"url": "/path"
+		}
+	}`
+
+	var message PingdomCheckMessage
+	if err := json.Unmarshal([]byte(payload), &message); err != nil {
+		t.Fatalf("failed to unmarshal the payload: %v", err)
+	}
+
+	typed, err := message.TypedCheckParams()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	params, ok := typed.(*HTTPParams)
+	if !ok {
+		t.Fatalf("expected *HTTPParams, got %T", typed)
+	}
+
+	if params.Hostname == nil || *params.Hostname != "www.example.com" {
+		t.Errorf("unexpected hostname: %v", params.Hostname)
+	}
+	if params.Port == nil || *params.Port != 443 {
+		t.Errorf("unexpected port: %v", params.Port)
+	}
+	if params.Encryption == nil || !*params.Encryption {
+		t.Errorf("unexpected encryption: %v", params.Encryption)
+	}
+	if params.VerifyCertificate != nil {
+		t.Errorf("expected absent verify_certificate, got %v", *params.VerifyCertificate)
+	}
+
+	message.CheckType = "BROWSER"
+	if _, err := message.TypedCheckParams(); !errors.Is(err, ErrUnknownCheckType) {
+		t.Errorf("expected ErrUnknownCheckType, got %v", err)
+	}
+}
+
+func TestTypedParams(t *testing.T) {
+	str := func(s string) *string { return &s }
+	yes, no := true, false
+	port := func(n int) *Number { p := Number(n); return &p }
+
+	for name, tc := range map[string]struct {
+		checkType string
+		params    map[string]interface{}
+		expected  interface{}
+	}{
+		"DNS": {
+			checkType: CheckTypeDNS,
+			params:    map[string]interface{}{"hostname": "example.com", "expected_ip": "192.0.2.1", "nameserver": "ns1.example.com", "ipv6": false},
+			expected: &DNSParams{
+				CommonParams: CommonParams{Hostname: str("example.com"), IPV6: &no},
+				ExpectedIP:   str("192.0.2.1"),
+				Nameserver:   str("ns1.example.com"),
+			},
+		},
+		"TCP": {
+			checkType: CheckTypeTCP,
+			params:    map[string]interface{}{"hostname": "example.com", "port": 5432, "stringtosend": "PING", "stringtoexpect": "PONG"},
+			expected: &TCPParams{
+				CommonParams:   CommonParams{Hostname: str("example.com")},
+				Port:           port(5432),
+				StringToSend:   str("PING"),
+				StringToExpect: str("PONG"),
+			},
+		},
+		"UDP with the string port": {
+			checkType: CheckTypeUDP,
+			params:    map[string]interface{}{"hostname": "example.com", "port": "53", "ipv6": true},
+			expected: &UDPParams{
+				CommonParams: CommonParams{Hostname: str("example.com"), IPV6: &yes},
+				Port:         port(53),
+			},
+		},
+		"SMTP with the float port": {
+			checkType: CheckTypeSMTP,
+			params:    map[string]interface{}{"hostname": "mail.example.com", "port": 25.0, "encryption": false, "stringtoexpect": "220"},
+			expected: &SMTPParams{MailParams{
+				CommonParams:   CommonParams{Hostname: str("mail.example.com")},
+				Port:           port(25),
+				Encryption:     &no,
+				StringToExpect: str("220"),
+			}},
+		},
+		"POP3": {
+			checkType: CheckTypePOP3,
+			params:    map[string]interface{}{"hostname": "mail.example.com", "port": 995, "encryption": true},
+			expected: &POP3Params{MailParams{
+				CommonParams: CommonParams{Hostname: str("mail.example.com")},
+				Port:         port(995),
+				Encryption:   &yes,
+			}},
+		},
+		"IMAP": {
+			checkType: CheckTypeIMAP,
+			params:    map[string]interface{}{"hostname": "mail.example.com", "port": "993", "stringtoexpect": "OK"},
+			expected: &IMAPParams{MailParams{
+				CommonParams:   CommonParams{Hostname: str("mail.example.com")},
+				Port:           port(993),
+				StringToExpect: str("OK"),
+			}},
+		},
+		"PING": {
+			checkType: CheckTypePing,
+			params:    map[string]interface{}{"hostname": "example.com", "ipv6": false},
+			expected:  &PingParams{CommonParams{Hostname: str("example.com"), IPV6: &no}},
+		},
+		"TRANSACTION": {
+			checkType: CheckTypeTransaction,
+			params:    map[string]interface{}{"full_url": "https://example.com/login", "port": 443, "encryption": true},
+			expected: &TransactionParams{
+				FullURL:    str("https://example.com/login"),
+				Port:       port(443),
+				Encryption: &yes,
+			},
+		},
+		"empty port": {
+			checkType: CheckTypeTCP,
+			params:    map[string]interface{}{"hostname": "example.com", "port": ""},
+			expected:  &TCPParams{CommonParams: CommonParams{Hostname: str("example.com")}, Port: port(0)},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			params, err := TypedParams(tc.checkType, tc.params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(params, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, params)
+			}
+		})
+	}
+}
+
+func TestNumberUnmarshalJSON(t *testing.T) {
+	for name, tc := range map[string]struct {
+		value       string
+		expected    Number
+		expectedErr bool
+	}{
+		"integer":         {value: `443`, expected: 443},
+		"float":           {value: `443.0`, expected: 443},
+		"string":          {value: `"443"`, expected: 443},
+		"empty string":    {value: `""`, expected: 0},
+		"fraction":        {value: `443.5`, expectedErr: true},
+		"not a number":    {value: `"https"`, expectedErr: true},
+		"out of range":    {value: `1e12`, expectedErr: true},
+		"boolean":         {value: `true`, expectedErr: true},
+		"negative string": {value: `"-1"`, expected: -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var n Number
+			err := json.Unmarshal([]byte(tc.value), &n)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected an error, got %d", n)
+				}
+				return
+			}
+			if err != nil || n != tc.expected {
+				t.Errorf("expected %d, got %d, %v", tc.expected, n, err)
+			}
+		})
+	}
+}
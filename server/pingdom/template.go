@@ -22,6 +22,10 @@ func (t *TimeString) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &s); err != nil {
 		return err
 	}
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
 	parsed, err := time.Parse("2006-01-02T15:04:05", s)
 	if err != nil {
 		return err
@@ -35,12 +39,32 @@ func (u *UnixTime) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &unixSec); err != nil {
 		return err
 	}
+	if unixSec == 0 {
+		u.Time = time.Time{}
+		return nil
+	}
 
 	// Convert Unix seconds to Go's time.Time
 	u.Time = time.Unix(unixSec, 0).UTC()
 	return nil
 }
 
+// MarshalJSON writes the time back in the same format Pingdom sends it.
+func (t TimeString) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return json.Marshal("")
+	}
+	return json.Marshal(t.UTC().Format("2006-01-02T15:04:05"))
+}
+
+// MarshalJSON writes the time back as a Unix timestamp (in seconds).
+func (u UnixTime) MarshalJSON() ([]byte, error) {
+	if u.IsZero() {
+		return json.Marshal(0)
+	}
+	return json.Marshal(u.Unix())
+}
+
 // KV is a set of key/value string pairs.
 type KV map[string]interface{}
 
@@ -115,4 +139,7 @@ type PingdomCheckMessage struct {
 	Description           string       `json:"short_description"`
 	FirstProbe            *FirstProbe  `json:"first_probe,omitempty"`
 	SecondProbe           *SecondProbe `json:"second_probe,omitempty"`
+
+	// IncidentID is only set for the legacy alerts (see legacy.go), they have no state change timestamp.
+	IncidentID uint64 `json:"incident_id,omitempty"`
 }
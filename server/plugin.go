@@ -1,16 +1,22 @@
 package main
 
 import (
-	"crypto/subtle"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/mattermost/mattermost/server/public/plugin"
 	pluginapi "github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
 
 	root "github.com/zentavr/mattermost-plugin-pingdom"
 )
@@ -27,15 +33,73 @@ type Plugin struct {
 	// setConfiguration for usage.
 	configuration *configuration
 
-	// key - pingdomHookConfig id, value - existing or created channel id received from api
-	PingdomHooksConfigIDChannelID map[string]string
-	BotUserID                     string
+	// channels caches the channels the hooks post to, see channels.go.
+	channels  hookChannels
+	BotUserID string
 
 	// configurationLock synchronizes access to the configuration.
 	configurationLock sync.RWMutex
+
+	// probesJob refreshes the addresses of the Pingdom probes, probes caches them.
+	probesJob *cluster.Job
+	probes    probesCache
+
+	// reconcileJob posts the state changes whose webhooks had been missed, see reconcile.go.
+	reconcileJob  *cluster.Job
+	reconcileLock *cluster.Mutex
+
+	// watchdogJob warns about the hooks which stopped receiving the alerts.
+	watchdogJob *cluster.Job
+
+	// historyJob prunes the history of the quiet checks and of the deleted hooks, see history.go.
+	historyJob *cluster.Job
+
+	// limiters keeps the rate limit state of the hooks.
+	limiters rateLimiters
+
+	// The delivery worker of the queued alerts, see queue.go.
+	deliveryLock   *cluster.Mutex
+	deliveryNotify chan struct{}
+	deliveryStop   chan struct{}
+	deliveryDone   chan struct{}
+
+	// stats collects the delivery stats of the hooks, see stats.go.
+	stats hookStatsBuffer
+
+	// metrics is the instrumentation served on /metrics, see metrics.go.
+	metrics pluginMetricsOnce
 }
 
 func (p *Plugin) OnDeactivate() error {
+	p.stopDeliveryWorker()
+
+	if p.probesJob != nil {
+		if err := p.probesJob.Close(); err != nil {
+			p.API.LogWarn("Failed to close the Pingdom probes job", "err", err.Error())
+		}
+		p.probesJob = nil
+	}
+
+	if p.reconcileJob != nil {
+		if err := p.reconcileJob.Close(); err != nil {
+			p.API.LogWarn("Failed to close the reconciliation job", "err", err.Error())
+		}
+		p.reconcileJob = nil
+	}
+
+	if p.watchdogJob != nil {
+		if err := p.watchdogJob.Close(); err != nil {
+			p.API.LogWarn("Failed to close the silence watchdog job", "err", err.Error())
+		}
+		p.watchdogJob = nil
+	}
+
+	if p.historyJob != nil {
+		if err := p.historyJob.Close(); err != nil {
+			p.API.LogWarn("Failed to close the history pruning job", "err", err.Error())
+		}
+		p.historyJob = nil
+	}
 	return nil
 }
 
@@ -56,18 +120,37 @@ func (p *Plugin) OnActivate() error {
 	}
 	p.BotUserID = botID
 
-	configuration := p.getConfiguration()
-	p.PingdomHooksConfigIDChannelID = make(map[string]string)
-	for k, pingdomHookConfig := range configuration.PingdomHooksConfigs {
-		var channelID string
-		channelID, err = p.ensureAlertChannelExists(pingdomHookConfig)
+	// The channels which fail to be resolved now are resolved again by the next alert.
+	p.resolveHookChannels()
+
+	// OnActivate is called on every configuration change as well, the job is scheduled once.
+	if p.probesJob == nil {
+		p.probesJob, err = cluster.Schedule(p.API, "RefreshPingdomProbes", cluster.MakeWaitForInterval(pingdomProbesRefreshInterval), p.refreshPingdomProbes)
 		if err != nil {
-			p.API.LogWarn(fmt.Sprintf("Failed to ensure alert channel %v", k), "error", err.Error())
-		} else {
-			p.PingdomHooksConfigIDChannelID[pingdomHookConfig.ID] = channelID
+			return fmt.Errorf("failed to schedule the Pingdom probes job: %w", err)
+		}
+	}
+	if p.watchdogJob == nil {
+		p.watchdogJob, err = cluster.Schedule(p.API, "SilenceWatchdog", cluster.MakeWaitForInterval(silenceWatchdogInterval), p.checkSilentHooks)
+		if err != nil {
+			return fmt.Errorf("failed to schedule the silence watchdog job: %w", err)
+		}
+	}
+	if p.historyJob == nil {
+		p.historyJob, err = cluster.Schedule(p.API, "PruneHistory", cluster.MakeWaitForInterval(historyPruneInterval), p.pruneAllHistory)
+		if err != nil {
+			return fmt.Errorf("failed to schedule the history pruning job: %w", err)
 		}
 	}
 
+	if err = p.startDeliveryWorker(); err != nil {
+		return fmt.Errorf("failed to start the delivery worker: %w", err)
+	}
+
+	if err = p.startReconciliation(); err != nil {
+		return err
+	}
+
 	p.API.LogDebug("Pingdom Notifications Plugin: creating commands.")
 	command, err := p.getCommand()
 	if err != nil {
@@ -104,12 +187,23 @@ func (p *Plugin) ensureAlertChannelExists(pingdomHookConfig pingdomHookConfig) (
 		return "", fmt.Errorf("Pingdom Configuration is invalid: %w", err)
 	}
 
+	switch pingdomHookConfig.GetTarget() {
+	case targetDirect:
+		return p.ensureDirectChannel(pingdomHookConfig)
+	case targetGroup:
+		return p.ensureGroupChannel(pingdomHookConfig)
+	}
+
 	team, appErr := p.API.GetTeamByName(pingdomHookConfig.Team)
 	if appErr != nil {
 		return "", fmt.Errorf("failed to get team: %w", appErr)
 	}
 
-	channel, appErr := p.API.GetChannelByName(team.Id, pingdomHookConfig.Channel, false)
+	// The archived channel keeps its name, it can not be created again.
+	channel, appErr := p.API.GetChannelByName(team.Id, pingdomHookConfig.Channel, true)
+	if appErr == nil && channel.DeleteAt != 0 {
+		return "", fmt.Errorf("the channel %s is archived", channel.Name)
+	}
 	if appErr != nil {
 		if appErr.StatusCode == http.StatusNotFound {
 			channelToCreate := &model.Channel{
@@ -119,16 +213,26 @@ func (p *Plugin) ensureAlertChannelExists(pingdomHookConfig pingdomHookConfig) (
 				TeamId:      team.Id,
 				CreatorId:   p.BotUserID,
 			}
+			if pingdomHookConfig.PrivateChannel {
+				channelToCreate.Type = model.ChannelTypePrivate
+			}
 
 			p.API.LogInfo(fmt.Sprintf("Creating alert pingdom channel %v", pingdomHookConfig.Channel))
 			newChannel, errChannel := p.API.CreateChannel(channelToCreate)
 			if errChannel != nil {
 				return "", fmt.Errorf("failed to create alert pingdom channel: %w", errChannel)
 			}
+			channel = newChannel
+		} else {
+			return "", fmt.Errorf("failed to get existing alert channel: %w", appErr)
+		}
+	}
 
-			return newChannel.Id, nil
+	// The bot has to be the member of the private channel.
+	if channel.Type == model.ChannelTypePrivate {
+		if err := p.ensureBotChannelMember(channel.Id); err != nil {
+			return "", err
 		}
-		return "", fmt.Errorf("failed to get existing alert channel: %w", appErr)
 	}
 
 	return channel.Id, nil
@@ -136,6 +240,16 @@ func (p *Plugin) ensureAlertChannelExists(pingdomHookConfig pingdomHookConfig) (
 
 func (p *Plugin) ServeHTTP(_ *plugin.Context, w http.ResponseWriter, r *http.Request) {
 	p.API.LogDebug(fmt.Sprintf("Pingdom Notifications Plugin: ServeHTTP is called."))
+	if strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		p.serveAPI(w, r)
+		return
+	}
+
+	if r.URL.Path == "/metrics" && r.Method == http.MethodGet {
+		p.serveMetrics(w, r)
+		return
+	}
+
 	if r.Method == http.MethodGet {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("Pingdom Notifications Plugin"))
@@ -143,39 +257,81 @@ func (p *Plugin) ServeHTTP(_ *plugin.Context, w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	invalidOrMissingSeedErr := "Invalid or missing seed"
-	seed := r.URL.Query().Get("seed")
-	if seed == "" {
-		p.API.LogWarn(fmt.Sprintf("The seed variable had not been provided in the URL request"))
-		http.Error(w, invalidOrMissingSeedErr, http.StatusBadRequest)
+	// The hook can be addressed by its ID (/api/webhook/{hookID}), so the reverse proxy rules, the logs
+	// and the metrics can be attributed to it. The seed is required anyway.
+	hookID, hasHookID := strings.CutPrefix(r.URL.Path, "/api/webhook/")
+	if r.URL.Path != "/api/webhook" && (!hasHookID || hookID == "" || strings.Contains(hookID, "/")) {
+		p.API.LogWarn(fmt.Sprintf("the endpoint not exists %s", r.URL.Path))
+		p.rejectWebhook(w, "", rejectUnknownHook, "404 page not found", http.StatusNotFound)
 		return
 	}
 
-	if len(seed) > 8 {
-		p.API.LogDebug(fmt.Sprintf("Pingdom Notifications Plugin: have seed: %s...%s", seed[:4], seed[len(seed)-4:]))
+	invalidOrMissingSeedErr := "Invalid or missing seed"
+	seed := r.URL.Query().Get("seed")
+	if seed == "" {
+		p.API.LogDebug(fmt.Sprintf("The seed variable had not been provided in the URL request, trying the other authentication methods"))
+	} else if len(seed) > 8 {
+		p.API.LogDebug(fmt.Sprintf("Pingdom Notifications Plugin: have seed: %s", seedHint(seed)))
 	} else {
 		p.API.LogDebug(fmt.Sprintf("Pingdom Notifications Plugin: seed is too short to print"))
 	}
 
+	// The body is read upfront as the HMAC signature is calculated over it.
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			p.API.LogWarn("The webhook request body is too large", "limit", maxBytesErr.Limit)
+			p.rejectWebhook(w, "", rejectBodyTooLarge, "Request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		p.API.LogWarn("failed to read the request body", "err", err.Error())
+		p.rejectWebhook(w, "", rejectInvalidPayload, "Failed to read the request body", http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
 	// URL Looks like: https://chat.example.com/plugins/com.zentavr.pingdom/api/webhook?seed=12345678901234567890axqiytMrAlY
+	// or https://chat.example.com/plugins/com.zentavr.pingdom/api/webhook/0?seed=12345678901234567890axqiytMrAlY
+	// (or the seed comes in the header, as the Basic password or as the HMAC key of the signature).
 	configuration := p.getConfiguration()
 
-	for _, pingdomHookConfig := range configuration.PingdomHooksConfigs {
-		if subtle.ConstantTimeCompare([]byte(seed), []byte(pingdomHookConfig.Seed)) == 1 && !pingdomHookConfig.Disabled {
-			switch r.URL.Path {
-			case "/api/webhook":
-				p.handleWebhook(w, r, pingdomHookConfig)
-			default:
-				p.API.LogWarn(fmt.Sprintf("the endpoint not exists %s", r.URL.Path))
-				http.NotFound(w, r)
-			}
-			return
-		} else {
-			p.API.LogWarn(fmt.Sprintf("The seed variable is invalid or the configuration is disabled"))
-			http.Error(w, invalidOrMissingSeedErr, http.StatusBadRequest)
-		}
+	var pingdomHookConfig pingdomHookConfig
+	var usedSeed string
+	if hasHookID {
+		pingdomHookConfig, usedSeed, err = configuration.findHookByID(hookID, r, body)
+	} else {
+		pingdomHookConfig, usedSeed, err = configuration.findHook(r, body)
+	}
+	if errors.Is(err, errUnknownHook) {
+		p.API.LogWarn(fmt.Sprintf("The webhook had been called for the unknown configuration %s", hookID))
+		p.rejectWebhook(w, "", rejectUnknownHook, "404 page not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errHookDisabled) {
+		p.API.LogWarn(fmt.Sprintf("The webhook had been called for the disabled configuration %s", pingdomHookConfig.ID))
+		p.rejectWebhook(w, pingdomHookConfig.ID, rejectDisabled, "Webhook is disabled", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		p.API.LogWarn(fmt.Sprintf("The seed variable is invalid or missing"))
+		p.rejectWebhook(w, "", rejectUnauthorized, invalidOrMissingSeedErr, http.StatusUnauthorized)
+		return
+	}
+
+	if addr, ok := p.isSourceAllowed(pingdomHookConfig, r); !ok {
+		p.API.LogWarn("The webhook had been called from the address which is not allowed", "hook_id", pingdomHookConfig.ID, "remote_addr", addr.String())
+		p.rejectWebhook(w, pingdomHookConfig.ID, rejectSourceNotAllowed, "Source address is not allowed", http.StatusForbidden)
+		return
+	}
+
+	p.recordSeedUsage(pingdomHookConfig, usedSeed)
+
+	if allowed, retryAfter := p.allowWebhookCall(pingdomHookConfig); !allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		p.rejectWebhook(w, pingdomHookConfig.ID, rejectRateLimited, "Too many alerts", http.StatusTooManyRequests)
+		return
 	}
 
-	p.API.LogDebug(fmt.Sprintf("It looks like we had not parsed our configuration or it is empty."))
-	http.Error(w, invalidOrMissingSeedErr, http.StatusBadRequest)
+	p.handleWebhook(w, r, pingdomHookConfig)
 }
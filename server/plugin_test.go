@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/plugin"
+	pluginapi "github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// logOnlyAPI is the plugin API which only supports logging: the tests using it must not reach
+// the rest of the API.
+type logOnlyAPI struct {
+	plugin.API
+}
+
+func (logOnlyAPI) LogDebug(string, ...interface{}) {}
+func (logOnlyAPI) LogInfo(string, ...interface{})  {}
+func (logOnlyAPI) LogWarn(string, ...interface{})  {}
+func (logOnlyAPI) LogError(string, ...interface{}) {}
+
+// fakeAPI is the plugin API with the in-memory KV store, teams, channels, posts and channel members. The system
+// admins are the only users having the permissions, the rest of the API is not supported.
+type fakeAPI struct {
+	logOnlyAPI
+
+	lock     sync.Mutex
+	kv       map[string][]byte
+	posts    map[string]*model.Post
+	admins   map[string]bool
+	members  map[string]map[string]bool
+	teams    map[string]*model.Team
+	channels map[string]*model.Channel
+	// postErr fails every CreatePost.
+	postErr *model.AppError
+}
+
+func newFakeAPI() *fakeAPI {
+	return &fakeAPI{
+		kv:       make(map[string][]byte),
+		posts:    make(map[string]*model.Post),
+		admins:   make(map[string]bool),
+		members:  make(map[string]map[string]bool),
+		teams:    make(map[string]*model.Team),
+		channels: make(map[string]*model.Channel),
+	}
+}
+
+// newTestPlugin returns the plugin using the fake API with the configuration.
+func newTestPlugin(api *fakeAPI, config *configuration) *Plugin {
+	p := &Plugin{}
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(api, nil)
+	p.setConfiguration(config)
+	return p
+}
+
+func (a *fakeAPI) addMember(channelID, userID string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.members[channelID] == nil {
+		a.members[channelID] = make(map[string]bool)
+	}
+	a.members[channelID][userID] = true
+}
+
+func (a *fakeAPI) KVSetWithOptions(key string, value []byte, options model.PluginKVSetOptions) (bool, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if options.Atomic {
+		current, ok := a.kv[key]
+		if options.OldValue == nil && ok || options.OldValue != nil && !bytes.Equal(current, options.OldValue) {
+			return false, nil
+		}
+	}
+	if value == nil {
+		delete(a.kv, key)
+	} else {
+		a.kv[key] = value
+	}
+	return true, nil
+}
+
+func (a *fakeAPI) KVGet(key string) ([]byte, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.kv[key], nil
+}
+
+func (a *fakeAPI) KVList(page, perPage int) ([]string, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	keys := make([]string, 0, len(a.kv))
+	for key := range a.kv {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	from, to := min(page*perPage, len(keys)), min((page+1)*perPage, len(keys))
+	return keys[from:to], nil
+}
+
+func (a *fakeAPI) HasPermissionTo(userID string, _ *model.Permission) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.admins[userID]
+}
+
+func (a *fakeAPI) GetUsers(*model.UserGetOptions) ([]*model.User, *model.AppError) {
+	return nil, nil
+}
+
+func (a *fakeAPI) GetTeamByName(name string) (*model.Team, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	team, ok := a.teams[name]
+	if !ok {
+		return nil, model.NewAppError("GetTeamByName", "not_found", nil, "", http.StatusNotFound)
+	}
+	return team, nil
+}
+
+func (a *fakeAPI) GetChannel(channelID string) (*model.Channel, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	channel, ok := a.channels[channelID]
+	if !ok {
+		return nil, model.NewAppError("GetChannel", "not_found", nil, "", http.StatusNotFound)
+	}
+	return channel.DeepCopy(), nil
+}
+
+func (a *fakeAPI) GetChannelByName(teamID, name string, includeDeleted bool) (*model.Channel, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, channel := range a.channels {
+		if channel.TeamId == teamID && channel.Name == name && (includeDeleted || channel.DeleteAt == 0) {
+			return channel.DeepCopy(), nil
+		}
+	}
+	return nil, model.NewAppError("GetChannelByName", "not_found", nil, "", http.StatusNotFound)
+}
+
+func (a *fakeAPI) CreateChannel(channel *model.Channel) (*model.Channel, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, existing := range a.channels {
+		if existing.TeamId == channel.TeamId && existing.Name == channel.Name {
+			return nil, model.NewAppError("CreateChannel", "exists", nil, "", http.StatusBadRequest)
+		}
+	}
+	channel = channel.DeepCopy()
+	channel.Id = model.NewId()
+	a.channels[channel.Id] = channel
+	return channel.DeepCopy(), nil
+}
+
+func (a *fakeAPI) GetUser(userID string) (*model.User, *model.AppError) {
+	return &model.User{Id: userID, Username: userID}, nil
+}
+
+func (a *fakeAPI) GetChannelMember(channelID, userID string) (*model.ChannelMember, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if !a.members[channelID][userID] {
+		return nil, model.NewAppError("GetChannelMember", "not_found", nil, "", http.StatusNotFound)
+	}
+	return &model.ChannelMember{ChannelId: channelID, UserId: userID}, nil
+}
+
+func (a *fakeAPI) CreatePost(post *model.Post) (*model.Post, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.postErr != nil {
+		return nil, a.postErr
+	}
+	post = post.Clone()
+	if post.Id == "" {
+		post.Id = model.NewId()
+	}
+	a.posts[post.Id] = post
+	return post.Clone(), nil
+}
+
+func (a *fakeAPI) GetPost(postID string) (*model.Post, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	post, ok := a.posts[postID]
+	if !ok {
+		return nil, model.NewAppError("GetPost", "not_found", nil, "", http.StatusNotFound)
+	}
+	return post.Clone(), nil
+}
+
+func (a *fakeAPI) UpdatePost(post *model.Post) (*model.Post, *model.AppError) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.posts[post.Id] = post.Clone()
+	return post.Clone(), nil
+}
+
+func TestServeHTTPRejections(t *testing.T) {
+	hooks := map[string]pingdomHookConfig{
+		"0": {ID: "0", Seed: "seed-0", Disabled: true},
+		"1": {ID: "1", Seed: "seed-1", Disabled: true},
+		"2": {ID: "2", Seed: "seed-2"},
+		"4": {ID: "4", Seed: "seed-4", AllowedCIDRs: "10.0.0.0/8"},
+	}
+	config := &configuration{PingdomHooksConfigs: hooks, MetricsToken: "metrics-token"}
+	config.seedIndex = newSeedIndex(hooks)
+
+	p := &Plugin{}
+	p.SetAPI(logOnlyAPI{})
+	p.setConfiguration(config)
+
+	for name, tc := range map[string]struct {
+		method   string
+		url      string
+		expected int
+	}{
+		"GET is informational": {
+			method:   http.MethodGet,
+			url:      "/api/webhook",
+			expected: http.StatusOK,
+		},
+		"unknown endpoint": {
+			method:   http.MethodPost,
+			url:      "/api/unknown?seed=seed-2",
+			expected: http.StatusNotFound,
+		},
+		"missing seed": {
+			method:   http.MethodPost,
+			url:      "/api/webhook",
+			expected: http.StatusUnauthorized,
+		},
+		"unknown seed": {
+			method:   http.MethodPost,
+			url:      "/api/webhook?seed=seed-3",
+			expected: http.StatusUnauthorized,
+		},
+		"first disabled hook": {
+			method:   http.MethodPost,
+			url:      "/api/webhook?seed=seed-0",
+			expected: http.StatusForbidden,
+		},
+		"second disabled hook": {
+			method:   http.MethodPost,
+			url:      "/api/webhook?seed=seed-1",
+			expected: http.StatusForbidden,
+		},
+		"unknown hook ID": {
+			method:   http.MethodPost,
+			url:      "/api/webhook/3?seed=seed-2",
+			expected: http.StatusNotFound,
+		},
+		"nested hook path": {
+			method:   http.MethodPost,
+			url:      "/api/webhook/2/extra?seed=seed-2",
+			expected: http.StatusNotFound,
+		},
+		"seed of another hook ID": {
+			method:   http.MethodPost,
+			url:      "/api/webhook/0?seed=seed-2",
+			expected: http.StatusUnauthorized,
+		},
+		"disabled hook ID": {
+			method:   http.MethodPost,
+			url:      "/api/webhook/1?seed=seed-1",
+			expected: http.StatusForbidden,
+		},
+		"metrics without the token": {
+			method:   http.MethodGet,
+			url:      "/metrics",
+			expected: http.StatusUnauthorized,
+		},
+		"source address not allowed": {
+			method:   http.MethodPost,
+			url:      "/api/webhook/4?seed=seed-4",
+			expected: http.StatusForbidden,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tc.method, tc.url, strings.NewReader("{}"))
+
+			p.ServeHTTP(nil, w, r)
+
+			if w.Code != tc.expected {
+				t.Errorf("expected status %d, got %d", tc.expected, w.Code)
+			}
+		})
+	}
+
+	m := p.getMetrics()
+	for _, tc := range []struct {
+		hook, reason string
+		expected     float64
+	}{
+		{hook: "", reason: rejectUnauthorized, expected: 3},
+		{hook: "1", reason: rejectDisabled, expected: 2},
+		{hook: "4", reason: rejectSourceNotAllowed, expected: 1},
+	} {
+		if got := testutil.ToFloat64(m.webhooksRejected.WithLabelValues(tc.hook, tc.reason)); got != tc.expected {
+			t.Errorf("expected %v calls of the hook %q rejected as %s, got %v", tc.expected, tc.hook, tc.reason, got)
+		}
+	}
+}
+
+func TestDownChecksMetric(t *testing.T) {
+	p := newTestPlugin(newFakeAPI(), &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{
+		"1": {ID: "1"},
+		"2": {ID: "2"},
+	}})
+
+	for _, state := range []checkState{
+		{HookID: "1", CheckID: 1, State: "DOWN"},
+		{HookID: "1", CheckID: 2, State: "FAILING"},
+		{HookID: "1", CheckID: 3, State: "UP"},
+		{HookID: "2", CheckID: 1, State: "SUCCESS"},
+		{HookID: "deleted", CheckID: 1, State: "DOWN"},
+	} {
+		if _, err := p.client.KV.Set(checkStateKey(state.HookID, state.CheckID), state); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := `
+# HELP pingdom_down_checks The checks which are currently DOWN or FAILING.
+# TYPE pingdom_down_checks gauge
+pingdom_down_checks{hook="1"} 2
+pingdom_down_checks{hook="2"} 0
+`
+	if err := testutil.CollectAndCompare(p.getMetrics().downChecks, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+const (
+	// deliveryQueuePrefix prefixes the KV keys of the queued alerts, the keys sort in the order
+	// the alerts had been received.
+	deliveryQueuePrefix = "delivery_queue_"
+	// deliveryQueueIndexKey keeps the keys of the queued alerts, so the worker does not list the
+	// whole KV store.
+	deliveryQueueIndexKey = "delivery_queue_index"
+	// deliveryQueueLockKey serializes the delivery across the nodes of the cluster.
+	deliveryQueueLockKey = "DeliveryQueue"
+
+	// deliveryPollInterval is how often the worker looks for the alerts due for the retry.
+	deliveryPollInterval = 5 * time.Second
+	// deliveryBaseBackoff is the delay of the first retry, it doubles with every attempt up to
+	// deliveryMaxBackoff.
+	deliveryBaseBackoff = 5 * time.Second
+	deliveryMaxBackoff  = 30 * time.Minute
+	// deliveryMaxAttempts is how many times the alert is tried before it is moved to the dead letters.
+	deliveryMaxAttempts = 12
+	// deliveryDrainTimeout limits how long OnDeactivate tries to deliver the queued alerts.
+	deliveryDrainTimeout = 10 * time.Second
+)
+
+// queuedAlert is the alert waiting for the delivery, it is kept in the KV store.
+type queuedAlert struct {
+	Key           string       `json:"key"`
+	HookID        string       `json:"hook_id"`
+	Message       uptime.Alert `json:"message"`
+	EnqueuedAt    int64        `json:"enqueued_at"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt int64        `json:"next_attempt_at"`
+	LastError     string       `json:"last_error,omitempty"`
+	// PostID is set once the alert had been posted, the alert is only removed from the queue then.
+	PostID string `json:"post_id,omitempty"`
+}
+
+// deliveryBackoff returns the delay before the next attempt after the given number of attempts.
+func deliveryBackoff(attempts int) time.Duration {
+	backoff := deliveryBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= deliveryMaxBackoff {
+			return deliveryMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// enqueueAlert stores the alert for the delivery and wakes the worker up.
+func (p *Plugin) enqueueAlert(hookID string, message uptime.Alert) error {
+	now := time.Now()
+	item := queuedAlert{
+		Key:           fmt.Sprintf("%s%020d_%s", deliveryQueuePrefix, now.UnixNano(), model.NewId()),
+		HookID:        hookID,
+		Message:       message,
+		EnqueuedAt:    now.UnixMilli(),
+		NextAttemptAt: now.UnixMilli(),
+	}
+	if _, err := p.client.KV.Set(item.Key, item); err != nil {
+		return fmt.Errorf("failed to queue the alert: %w", err)
+	}
+	err := p.updateQueueIndex(func(keys []string) []string {
+		return append(keys, item.Key)
+	})
+	if err != nil {
+		if deleteErr := p.client.KV.Delete(item.Key); deleteErr != nil {
+			p.API.LogWarn("failed to remove the unindexed alert", "key", item.Key, "err", deleteErr.Error())
+		}
+		return fmt.Errorf("failed to queue the alert: %w", err)
+	}
+
+	select {
+	case p.deliveryNotify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// listQueuedAlerts returns the keys of the queued alerts in the order they had been received.
+func (p *Plugin) listQueuedAlerts() ([]string, error) {
+	var keys []string
+	if err := p.client.KV.Get(deliveryQueueIndexKey, &keys); err != nil {
+		return nil, fmt.Errorf("failed to list the queued alerts: %w", err)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// updateQueueIndex modifies the index of the queued alerts atomically.
+func (p *Plugin) updateQueueIndex(update func([]string) []string) error {
+	return p.client.KV.SetAtomicWithRetries(deliveryQueueIndexKey, func(oldValue []byte) (interface{}, error) {
+		var keys []string
+		if len(oldValue) > 0 {
+			if err := json.Unmarshal(oldValue, &keys); err != nil {
+				return nil, err
+			}
+		}
+		keys = update(keys)
+		if len(keys) == 0 {
+			return nil, nil
+		}
+		return keys, nil
+	})
+}
+
+// startDeliveryWorker starts the worker delivering the queued alerts. OnActivate is called on every
+// configuration change as well, the worker is started once.
+func (p *Plugin) startDeliveryWorker() error {
+	if p.deliveryStop != nil {
+		return nil
+	}
+
+	lock, err := cluster.NewMutex(p.API, deliveryQueueLockKey)
+	if err != nil {
+		return fmt.Errorf("failed to create the delivery queue lock: %w", err)
+	}
+	p.deliveryLock = lock
+	p.deliveryNotify = make(chan struct{}, 1)
+	p.deliveryStop = make(chan struct{})
+	p.deliveryDone = make(chan struct{})
+
+	go p.runDeliveryWorker(p.deliveryStop, p.deliveryDone)
+	return nil
+}
+
+func (p *Plugin) runDeliveryWorker(stop, done chan struct{}) {
+	defer close(done)
+
+	// The worker waiting for the lock held by another node is interrupted by the stop.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(deliveryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		p.processDeliveryQueue(ctx, false)
+		p.flushHookStats()
+
+		select {
+		case <-stop:
+			return
+		case <-p.deliveryNotify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// stopDeliveryWorker stops the worker and drains the queue: every queued alert is tried once more,
+// regardless of its backoff. The alerts which still fail stay queued for the next activation.
+func (p *Plugin) stopDeliveryWorker() {
+	if p.deliveryStop == nil {
+		return
+	}
+
+	close(p.deliveryStop)
+	<-p.deliveryDone
+	p.deliveryStop = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryDrainTimeout)
+	defer cancel()
+	p.processDeliveryQueue(ctx, true)
+	p.flushHookStats()
+}
+
+// processDeliveryQueue delivers the queued alerts which are due. Only one node of the cluster
+// processes the queue at a time.
+func (p *Plugin) processDeliveryQueue(ctx context.Context, drain bool) {
+	if err := p.deliveryLock.LockWithContext(ctx); err != nil {
+		p.API.LogWarn("failed to lock the delivery queue", "err", err.Error())
+		return
+	}
+	defer p.deliveryLock.Unlock()
+
+	keys, err := p.listQueuedAlerts()
+	if err != nil {
+		p.API.LogWarn("failed to process the delivery queue", "err", err.Error())
+		return
+	}
+
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			p.API.LogWarn("The delivery queue is not drained", "err", ctx.Err().Error())
+			return
+		}
+
+		var item *queuedAlert
+		if err := p.client.KV.Get(key, &item); err != nil {
+			p.API.LogWarn("failed to get the queued alert", "key", key, "err", err.Error())
+			continue
+		}
+		if item == nil {
+			// The alert is removed, but its key had been left in the index.
+			if err := p.removeQueuedAlert(&queuedAlert{Key: key}); err != nil {
+				p.API.LogWarn("failed to remove the queued alert", "key", key, "err", err.Error())
+			}
+			continue
+		}
+		if !drain && time.Now().UnixMilli() < item.NextAttemptAt {
+			continue
+		}
+
+		p.deliverQueuedAlert(item)
+	}
+}
+
+// deliverQueuedAlert tries to deliver the alert, it is removed from the queue once it is delivered
+// or given up, otherwise it is scheduled for the retry.
+func (p *Plugin) deliverQueuedAlert(item *queuedAlert) {
+	// The alert had been posted, but it failed to be removed from the queue.
+	if item.PostID != "" {
+		p.dequeueAlert(item)
+		return
+	}
+
+	config, ok := p.getConfiguration().PingdomHooksConfigs[item.HookID]
+	if !ok {
+		p.API.LogWarn("Dropping the queued alert of the removed hook", "hook_id", item.HookID, "check_id", item.Message.CheckID)
+		p.dequeueAlert(item)
+		return
+	}
+
+	postID, err := p.deliverAlert(config, item.Message)
+	if err == nil {
+		item.PostID = postID
+		if err := p.removeQueuedAlert(item); err != nil {
+			// The item is marked as posted, so it is not posted again.
+			p.API.LogWarn("failed to remove the delivered alert", "key", item.Key, "err", err.Error())
+			if _, err := p.client.KV.Set(item.Key, item); err != nil {
+				p.API.LogWarn("failed to mark the queued alert as posted", "key", item.Key, "err", err.Error())
+			}
+		}
+		return
+	}
+
+	item.Attempts++
+	item.LastError = err.Error()
+	if item.Attempts >= deliveryMaxAttempts {
+		p.API.LogError("Giving up the delivery of the alert, moving it to the dead letters", "hook_id", item.HookID,
+			"check_id", item.Message.CheckID, "attempts", item.Attempts, "err", item.LastError)
+		deadLetterErr := p.addDeadLetter(item)
+		if deadLetterErr == nil {
+			p.dequeueAlert(item)
+			return
+		}
+		// The alert stays queued, so it is not lost.
+		p.API.LogWarn("failed to store the dead letter", "hook_id", item.HookID, "err", deadLetterErr.Error())
+	}
+
+	backoff := deliveryBackoff(item.Attempts)
+	item.NextAttemptAt = time.Now().Add(backoff).UnixMilli()
+	p.API.LogWarn("failed to deliver the alert, retrying later", "hook_id", item.HookID, "check_id", item.Message.CheckID,
+		"attempts", item.Attempts, "retry_in", backoff.String(), "err", item.LastError)
+	if _, err := p.client.KV.Set(item.Key, item); err != nil {
+		p.API.LogWarn("failed to reschedule the queued alert", "key", item.Key, "err", err.Error())
+	}
+}
+
+// dequeueAlert removes the alert from the queue, the failure is logged only.
+func (p *Plugin) dequeueAlert(item *queuedAlert) {
+	if err := p.removeQueuedAlert(item); err != nil {
+		p.API.LogWarn("failed to remove the queued alert", "key", item.Key, "err", err.Error())
+	}
+}
+
+// removeQueuedAlert removes the alert from the index and the KV store.
+func (p *Plugin) removeQueuedAlert(item *queuedAlert) error {
+	err := p.updateQueueIndex(func(keys []string) []string {
+		kept := keys[:0]
+		for _, key := range keys {
+			if key != item.Key {
+				kept = append(kept, key)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove the queued alert from the index: %w", err)
+	}
+	if err := p.client.KV.Delete(item.Key); err != nil {
+		return fmt.Errorf("failed to remove the queued alert: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+func TestDeliveryBackoff(t *testing.T) {
+	for attempts, expected := range map[int]time.Duration{
+		1:  5 * time.Second,
+		2:  10 * time.Second,
+		3:  20 * time.Second,
+		6:  160 * time.Second,
+		9:  1280 * time.Second,
+		10: deliveryMaxBackoff,
+		50: deliveryMaxBackoff,
+	} {
+		if backoff := deliveryBackoff(attempts); backoff != expected {
+			t.Errorf("expected the backoff after %d attempts to be %v, got %v", attempts, expected, backoff)
+		}
+	}
+}
+
+func TestProcessDeliveryQueue(t *testing.T) {
+	api := newFakeAPI()
+	config := pingdomHookConfig{ID: "0", Team: "team", Channel: "alerts", Seed: "seed-0"}
+	p := newTestPlugin(api, &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{"0": config}})
+	lock, err := cluster.NewMutex(api, deliveryQueueLockKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.deliveryLock = lock
+
+	queued := func() []*queuedAlert {
+		t.Helper()
+		keys, err := p.listQueuedAlerts()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		items := make([]*queuedAlert, 0, len(keys))
+		for _, key := range keys {
+			var item *queuedAlert
+			if err := p.client.KV.Get(key, &item); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			items = append(items, item)
+		}
+		return items
+	}
+
+	message := uptime.Alert{CheckID: 1, CheckName: "web", CurrentState: "DOWN", PreviousState: "UP", StateChangedTimestamp: 1700000000}
+	if err := p.enqueueAlert("0", message); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The failed post is retried after the backoff.
+	p.channels.set("0", "channel-0", time.Now())
+	api.postErr = model.NewAppError("CreatePost", "unavailable", nil, "", http.StatusServiceUnavailable)
+	p.processDeliveryQueue(context.Background(), false)
+	items := queued()
+	if len(items) != 1 || items[0].Attempts != 1 || items[0].LastError == "" || items[0].NextAttemptAt <= time.Now().UnixMilli() {
+		t.Fatalf("expected the alert to be scheduled for the retry, got %+v", items)
+	}
+	p.channels.set("0", "channel-0", time.Now())
+	p.processDeliveryQueue(context.Background(), false)
+	if items = queued(); items[0].Attempts != 1 {
+		t.Errorf("expected the alert not to be retried before the backoff, got %d attempts", items[0].Attempts)
+	}
+
+	// The delivered alert is removed from the queue.
+	api.postErr = nil
+	p.processDeliveryQueue(context.Background(), true)
+	if items = queued(); len(items) != 0 || len(api.posts) != 1 {
+		t.Fatalf("expected the alert to be posted and removed from the queue, got %d queued, %d posts", len(items), len(api.posts))
+	}
+
+	// The posted alert which failed to leave the queue is not posted again.
+	if err := p.enqueueAlert("0", message); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items = queued()
+	items[0].PostID = "post-id"
+	if _, err := p.client.KV.Set(items[0].Key, items[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.processDeliveryQueue(context.Background(), true)
+	if items = queued(); len(items) != 0 || len(api.posts) != 1 {
+		t.Fatalf("expected the posted alert to be removed only, got %d queued, %d posts", len(items), len(api.posts))
+	}
+
+	// The alert failing the last attempt is moved to the dead letters.
+	if err := p.enqueueAlert("0", message); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	items = queued()
+	items[0].Attempts = deliveryMaxAttempts - 1
+	if _, err := p.client.KV.Set(items[0].Key, items[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	api.postErr = model.NewAppError("CreatePost", "unavailable", nil, "", http.StatusServiceUnavailable)
+	p.processDeliveryQueue(context.Background(), true)
+	if items = queued(); len(items) != 0 {
+		t.Errorf("expected the alert to leave the queue, got %+v", items)
+	}
+	letters, err := p.getDeadLetters()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(letters) != 1 || letters[0].HookID != "0" || letters[0].Message.CheckID != 1 {
+		t.Errorf("expected the alert in the dead letters, got %+v", letters)
+	}
+
+	var index []byte
+	if err := p.client.KV.Get(deliveryQueueIndexKey, &index); err != nil || index != nil {
+		t.Errorf("expected the empty index to be removed, got %s, %v", index, err)
+	}
+}
+
+func TestDeliveryWorkerStop(t *testing.T) {
+	api := newFakeAPI()
+	p := newTestPlugin(api, &configuration{})
+	lock, err := cluster.NewMutex(api, deliveryQueueLockKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p.deliveryLock = lock
+	p.deliveryNotify = make(chan struct{}, 1)
+
+	// Another node holds the lock of the queue.
+	other, err := cluster.NewMutex(api, deliveryQueueLockKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other.Lock()
+	defer other.Unlock()
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	go p.runDeliveryWorker(stop, done)
+	close(stop)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the worker waiting for the lock to stop")
+	}
+}
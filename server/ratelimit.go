@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// maxWebhookBodySize limits the body of the webhook call, the Pingdom alerts are a few KiB at most.
+const maxWebhookBodySize = 256 << 10
+
+// defaultRateLimitPerMinute and defaultRateLimitBurst are used when the hook does not define its own limits.
+const (
+	defaultRateLimitPerMinute = 60
+	defaultRateLimitBurst     = 20
+)
+
+// GetRateLimit returns the sustained number of the alerts per minute and the burst the hook accepts.
+func (ac *pingdomHookConfig) GetRateLimit() (perMinute, burst int) {
+	perMinute, burst = ac.RateLimitPerMinute, ac.RateLimitBurst
+	if perMinute <= 0 {
+		perMinute = defaultRateLimitPerMinute
+	}
+	if burst <= 0 {
+		burst = defaultRateLimitBurst
+	}
+	return perMinute, burst
+}
+
+// tokenBucket is the classic token bucket: it holds up to burst tokens and refills at rate tokens
+// per second, every call takes one token.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(perMinute, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(perMinute) / 60,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// take takes the token if there is one, otherwise it returns how long to wait for the next one.
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// hookLimiter is the rate limit state of the hook. The limits are kept per node, so the hook
+// accepts up to the limit on every node of the cluster.
+type hookLimiter struct {
+	lock sync.Mutex
+
+	perMinute int
+	burst     int
+	bucket    *tokenBucket
+
+	// throttledPost is the "alerts are being throttled" post of the ongoing throttling, it gets the
+	// summary once the alerts are accepted again.
+	throttledPost  *model.Post
+	throttledSince time.Time
+	dropped        int
+	// episode counts the throttlings, ended is the summary of the last one. The notice is posted
+	// outside of the lock, so the throttling may end before the notice is created.
+	episode int
+	ended   *throttleSummary
+}
+
+// throttleSummary is the throttling which had ended, the notice of the throttling is updated with it.
+type throttleSummary struct {
+	episode int
+	post    *model.Post
+	since   time.Time
+	until   time.Time
+	dropped int
+}
+
+type rateLimiters struct {
+	lock  sync.Mutex
+	hooks map[string]*hookLimiter
+}
+
+// get returns the limiter of the hook, it is reset when the limits of the hook change.
+func (rl *rateLimiters) get(config pingdomHookConfig, now time.Time) *hookLimiter {
+	perMinute, burst := config.GetRateLimit()
+
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	if rl.hooks == nil {
+		rl.hooks = make(map[string]*hookLimiter)
+	}
+	limiter, ok := rl.hooks[config.ID]
+	if !ok || limiter.perMinute != perMinute || limiter.burst != burst {
+		limiter = &hookLimiter{
+			perMinute: perMinute,
+			burst:     burst,
+			bucket:    newTokenBucket(perMinute, burst, now),
+		}
+		rl.hooks[config.ID] = limiter
+	}
+	return limiter
+}
+
+// allowWebhookCall applies the rate limit of the hook. The first dropped alert posts the single
+// "alerts are being throttled" notice to the channel, the first accepted alert after that
+// updates it with the number of the dropped alerts. The limiter is locked only to update the
+// counters, the posts are created and updated after it is unlocked.
+func (p *Plugin) allowWebhookCall(config pingdomHookConfig) (bool, time.Duration) {
+	now := time.Now()
+	limiter := p.limiters.get(config, now)
+
+	limiter.lock.Lock()
+	allowed, retryAfter := limiter.bucket.take(now)
+	if !allowed {
+		limiter.dropped++
+		first := limiter.dropped == 1
+		if first {
+			limiter.episode++
+			limiter.throttledSince = now
+		}
+		episode := limiter.episode
+		limiter.lock.Unlock()
+
+		if first {
+			p.API.LogWarn("The webhook calls are being throttled", "hook_id", config.ID)
+			p.startThrottling(config, limiter, episode)
+		}
+		return false, retryAfter
+	}
+
+	var summary *throttleSummary
+	if limiter.dropped > 0 {
+		summary = &throttleSummary{
+			episode: limiter.episode,
+			post:    limiter.throttledPost,
+			since:   limiter.throttledSince,
+			until:   now,
+			dropped: limiter.dropped,
+		}
+		limiter.ended = summary
+		limiter.throttledPost = nil
+		limiter.dropped = 0
+	}
+	limiter.lock.Unlock()
+
+	if summary != nil {
+		p.API.LogInfo("The webhook calls are not throttled anymore", "hook_id", config.ID, "dropped", summary.dropped)
+		p.postThrottledSummary(config, *summary)
+	}
+	return true, 0
+}
+
+// startThrottling posts the throttling notice. When the throttling had ended while the notice was
+// being posted, the notice gets the summary right away.
+func (p *Plugin) startThrottling(config pingdomHookConfig, limiter *hookLimiter, episode int) {
+	post := p.postThrottled(config)
+	if post == nil {
+		return
+	}
+
+	limiter.lock.Lock()
+	if limiter.episode == episode && limiter.dropped > 0 {
+		limiter.throttledPost = post
+		limiter.lock.Unlock()
+		return
+	}
+	ended := limiter.ended
+	limiter.lock.Unlock()
+
+	if ended != nil && ended.episode == episode {
+		summary := *ended
+		summary.post = post
+		p.postThrottledSummary(config, summary)
+	}
+}
+
+func (p *Plugin) postThrottled(config pingdomHookConfig) *model.Post {
+	channelID, err := p.getHookChannelID(config)
+	if err != nil {
+		p.API.LogWarn("failed to post the throttling notice", "hook_id", config.ID, "err", err.Error())
+		return nil
+	}
+
+	perMinute, burst := config.GetRateLimit()
+	post := &model.Post{
+		ChannelId: channelID,
+		UserId:    p.BotUserID,
+		Message: fmt.Sprintf(":warning: Pingdom alerts are being throttled: the webhook accepts %d alerts per minute "+
+			"(bursts of %d). The alerts over the limit are dropped until the rate goes down.", perMinute, burst),
+	}
+	createdPost, appErr := p.API.CreatePost(post)
+	if appErr != nil {
+		p.API.LogWarn("failed to post the throttling notice", "hook_id", config.ID, "err", appErr.Error())
+		return nil
+	}
+	return createdPost
+}
+
+func (p *Plugin) postThrottledSummary(config pingdomHookConfig, summary throttleSummary) {
+	if summary.post == nil {
+		return
+	}
+
+	loc, err := config.GetLocation()
+	if err != nil {
+		loc = time.UTC
+	}
+	layout := config.GetTimeLayout()
+
+	post := summary.post.Clone()
+	post.Message = fmt.Sprintf(":warning: Pingdom alerts were throttled from %s to %s, %d alerts were dropped. "+
+		"Check the Pingdom checks for flapping or the seed for a leak.",
+		summary.since.In(loc).Format(layout), summary.until.In(loc).Format(layout), summary.dropped)
+	if _, appErr := p.API.UpdatePost(post); appErr != nil {
+		p.API.LogWarn("failed to post the throttling summary", "hook_id", config.ID, "err", appErr.Error())
+	}
+}
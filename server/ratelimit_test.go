@@ -0,0 +1,134 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	pluginapi "github.com/mattermost/mattermost/server/public/pluginapi"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	bucket := newTokenBucket(60, 3, now)
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := bucket.take(now); !allowed {
+			t.Fatalf("expected the call %d of the burst to be allowed", i)
+		}
+	}
+
+	allowed, retryAfter := bucket.take(now)
+	if allowed {
+		t.Fatalf("expected the call over the burst to be throttled")
+	}
+	if retryAfter != time.Second {
+		t.Errorf("expected retry after %v, got %v", time.Second, retryAfter)
+	}
+
+	if allowed, _ := bucket.take(now.Add(time.Second)); !allowed {
+		t.Errorf("expected the call to be allowed after the refill")
+	}
+	if allowed, _ := bucket.take(now.Add(time.Second)); allowed {
+		t.Errorf("expected the second call to be throttled")
+	}
+
+	// The bucket never holds more than the burst.
+	later := now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if allowed, _ := bucket.take(later); !allowed {
+			t.Fatalf("expected the call %d of the burst to be allowed", i)
+		}
+	}
+	if allowed, _ := bucket.take(later); allowed {
+		t.Errorf("expected the call over the burst to be throttled")
+	}
+}
+
+func TestRateLimitersReset(t *testing.T) {
+	var limiters rateLimiters
+	now := time.Now()
+
+	config := pingdomHookConfig{ID: "0", RateLimitPerMinute: 1, RateLimitBurst: 1}
+	limiter := limiters.get(config, now)
+	if limiters.get(config, now) != limiter {
+		t.Errorf("expected the same limiter for the same limits")
+	}
+
+	config.RateLimitBurst = 2
+	if limiters.get(config, now) == limiter {
+		t.Errorf("expected a new limiter for the changed limits")
+	}
+}
+
+// blockingPostAPI holds CreatePost until it is released.
+type blockingPostAPI struct {
+	*fakeAPI
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (a *blockingPostAPI) CreatePost(post *model.Post) (*model.Post, *model.AppError) {
+	a.entered <- struct{}{}
+	<-a.release
+	return a.fakeAPI.CreatePost(post)
+}
+
+func TestAllowWebhookCallThrottling(t *testing.T) {
+	api := &blockingPostAPI{fakeAPI: newFakeAPI(), entered: make(chan struct{}), release: make(chan struct{})}
+	config := pingdomHookConfig{ID: "0", Team: "team", Channel: "alerts", RateLimitPerMinute: 1, RateLimitBurst: 1}
+	p := &Plugin{}
+	p.SetAPI(api)
+	p.client = pluginapi.NewClient(api, nil)
+	p.setConfiguration(&configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{"0": config}})
+	p.channels.set("0", "channel-0", time.Now())
+
+	if allowed, _ := p.allowWebhookCall(config); !allowed {
+		t.Fatal("expected the first call to be allowed")
+	}
+
+	// The first dropped call posts the notice, the other calls do not wait for it.
+	noticed := make(chan struct{})
+	go func() {
+		defer close(noticed)
+		if allowed, _ := p.allowWebhookCall(config); allowed {
+			t.Error("expected the call over the limit to be dropped")
+		}
+	}()
+	<-api.entered
+
+	dropped := make(chan bool)
+	go func() {
+		allowed, _ := p.allowWebhookCall(config)
+		dropped <- !allowed
+	}()
+	select {
+	case ok := <-dropped:
+		if !ok {
+			t.Error("expected the call over the limit to be dropped")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the call not to wait for the throttling notice")
+	}
+
+	// The throttling ends before the notice is created, the notice gets the summary.
+	limiter := p.limiters.get(config, time.Now())
+	limiter.lock.Lock()
+	limiter.bucket.tokens = 1
+	limiter.lock.Unlock()
+	if allowed, _ := p.allowWebhookCall(config); !allowed {
+		t.Fatal("expected the call to be allowed again")
+	}
+	close(api.release)
+	<-noticed
+
+	if len(api.posts) != 1 {
+		t.Fatalf("expected the single throttling notice, got %d posts", len(api.posts))
+	}
+	for _, post := range api.posts {
+		if !strings.Contains(post.Message, "2 alerts were dropped") {
+			t.Errorf("expected the notice to get the summary, got %q", post.Message)
+		}
+	}
+}
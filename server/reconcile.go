@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/pluginapi/cluster"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+// reconcileInterval is how often the check states are compared with the Pingdom API.
+const reconcileInterval = 10 * time.Minute
+
+// reconcileLockKey serializes the reconciliation on activation and the scheduled one.
+const reconcileLockKey = "ReconcileChecks"
+
+// reconciledTransition returns the state change the hook had missed: the state of the check in the
+// Pingdom API differs from the state recorded last time. The checks which are paused or whose state
+// is not confirmed yet are skipped, as well as the checks the hook had never received the alerts of:
+// the account may have the checks alerting to other integrations.
+func reconciledTransition(check pingdom.Check, recorded *checkState) (uptime.Alert, bool) {
+	if recorded == nil {
+		return uptime.Alert{}, false
+	}
+
+	var state string
+	var changedAt int64
+	switch check.Status {
+	case pingdom.CheckStatusDown:
+		state, changedAt = uptime.StateDown, check.LastErrorTime
+	case pingdom.CheckStatusUp:
+		state, changedAt = uptime.StateUp, check.LastTestTime
+	default:
+		return uptime.Alert{}, false
+	}
+
+	previous := recorded.State
+	if previous == state {
+		return uptime.Alert{}, false
+	}
+	// The transaction checks report FAILING and SUCCESS, the API tells DOWN and UP.
+	if (previous == "FAILING" && state == uptime.StateDown) || (previous == "SUCCESS" && state == uptime.StateUp) {
+		return uptime.Alert{}, false
+	}
+
+	return uptime.Alert{
+		Provider:              uptime.ProviderPingdom,
+		CheckID:               check.ID,
+		CheckName:             check.Name,
+		CheckType:             strings.ToUpper(check.Type),
+		PreviousState:         previous,
+		CurrentState:          state,
+		StateChangedTimestamp: changedAt,
+		Description:           fmt.Sprintf("The check is %s according to the Pingdom API", state),
+		LongDescription:       "The webhook of this state change had not been received (e.g. while the plugin was disabled), it is reconciled from the Pingdom API.",
+		Reconciled:            true,
+	}, true
+}
+
+// startReconciliation schedules the reconciliation and runs it right away, the webhooks may have
+// been lost while the plugin was not active. OnActivate is called on every configuration change as
+// well, the job is scheduled once.
+func (p *Plugin) startReconciliation() error {
+	if p.reconcileJob != nil {
+		return nil
+	}
+
+	lock, err := cluster.NewMutex(p.API, reconcileLockKey)
+	if err != nil {
+		return fmt.Errorf("failed to create the reconciliation lock: %w", err)
+	}
+	p.reconcileLock = lock
+
+	p.reconcileJob, err = cluster.Schedule(p.API, "ReconcilePingdomChecks", cluster.MakeWaitForInterval(reconcileInterval), p.reconcileChecks)
+	if err != nil {
+		return fmt.Errorf("failed to schedule the reconciliation job: %w", err)
+	}
+	go p.reconcileChecks()
+	return nil
+}
+
+// reconcileChecks posts the state changes the hooks had missed. Only one node of the cluster
+// reconciles at a time, the alerts are deduplicated the same way as the webhook calls.
+func (p *Plugin) reconcileChecks() {
+	p.reconcileLock.Lock()
+	defer p.reconcileLock.Unlock()
+
+	queued, err := p.queuedChecks()
+	if err != nil {
+		p.API.LogWarn("Failed to reconcile the check states", "err", err.Error())
+		return
+	}
+
+	for _, config := range p.getConfiguration().PingdomHooksConfigs {
+		if config.Disabled || config.Token == "" || config.GetProvider() != uptime.ProviderPingdom {
+			continue
+		}
+		if err := p.reconcileHook(config, queued); err != nil {
+			p.API.LogWarn("Failed to reconcile the check states", "hook_id", config.ID, "err", err.Error())
+		}
+	}
+}
+
+func (p *Plugin) reconcileHook(config pingdomHookConfig, queued map[string]bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	recorded, err := p.listCheckStates(config.ID)
+	if err != nil {
+		return err
+	}
+	if len(recorded) == 0 {
+		return nil
+	}
+
+	checks, err := p.newPingdomClient(config.Token).GetChecks(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, check := range checks {
+		// The queued alert is not recorded as the check state until it is posted.
+		if queued[checkStateKey(config.ID, check.ID)] {
+			continue
+		}
+		message, missed := reconciledTransition(check, recorded[check.ID])
+		if !missed {
+			continue
+		}
+
+		p.API.LogInfo("Reconciling the missed state change", "hook_id", config.ID, "check_id", check.ID, "state", message.CurrentState)
+		if _, err := p.acceptAlert(config, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queuedChecks returns the checks with the alerts waiting for the delivery, by checkStateKey.
+func (p *Plugin) queuedChecks() (map[string]bool, error) {
+	keys, err := p.listQueuedAlerts()
+	if err != nil {
+		return nil, err
+	}
+
+	queued := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		var item *queuedAlert
+		if err := p.client.KV.Get(key, &item); err != nil {
+			return nil, fmt.Errorf("failed to get the queued alert: %w", err)
+		}
+		if item != nil {
+			queued[checkStateKey(item.HookID, item.Message.CheckID)] = true
+		}
+	}
+	return queued, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+)
+
+func TestReconciledTransition(t *testing.T) {
+	down := pingdom.Check{ID: 1, Name: "web", Type: "http", Status: pingdom.CheckStatusDown, LastErrorTime: 1700000000, LastTestTime: 1700000300}
+	up := pingdom.Check{ID: 1, Name: "web", Type: "http", Status: pingdom.CheckStatusUp, LastErrorTime: 1700000000, LastTestTime: 1700000300}
+
+	for name, tc := range map[string]struct {
+		check     pingdom.Check
+		recorded  *checkState
+		missed    bool
+		previous  string
+		state     string
+		changedAt int64
+	}{
+		"missed DOWN": {
+			check:     down,
+			recorded:  &checkState{State: "UP"},
+			missed:    true,
+			previous:  "UP",
+			state:     "DOWN",
+			changedAt: 1700000000,
+		},
+		"missed UP": {
+			check:     up,
+			recorded:  &checkState{State: "DOWN"},
+			missed:    true,
+			previous:  "DOWN",
+			state:     "UP",
+			changedAt: 1700000300,
+		},
+		"DOWN of the unknown check": {
+			check: down,
+		},
+		"UP of the unknown check": {
+			check: up,
+		},
+		"same state": {
+			check:    down,
+			recorded: &checkState{State: "DOWN"},
+		},
+		"failing transaction": {
+			check:    down,
+			recorded: &checkState{State: "FAILING"},
+		},
+		"paused": {
+			check:    pingdom.Check{ID: 1, Status: pingdom.CheckStatusPaused},
+			recorded: &checkState{State: "UP"},
+		},
+		"unconfirmed down": {
+			check:    pingdom.Check{ID: 1, Status: pingdom.CheckStatusUnconfirmed},
+			recorded: &checkState{State: "UP"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			message, missed := reconciledTransition(tc.check, tc.recorded)
+			if missed != tc.missed {
+				t.Fatalf("expected missed %v, got %v", tc.missed, missed)
+			}
+			if !missed {
+				return
+			}
+			if !message.Reconciled || message.CheckType != "HTTP" || message.CheckID != tc.check.ID {
+				t.Errorf("unexpected reconciled alert: %+v", message)
+			}
+			if message.PreviousState != tc.previous || message.CurrentState != tc.state || message.StateChangedTimestamp != tc.changedAt {
+				t.Errorf("expected %q → %q at %d, got %q → %q at %d", tc.previous, tc.state, tc.changedAt,
+					message.PreviousState, message.CurrentState, message.StateChangedTimestamp)
+			}
+		})
+	}
+}
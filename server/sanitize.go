@@ -0,0 +1,88 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// The webhook payload is not trusted: the check names, descriptions, tags and URLs are typed in by
+// whoever has access to the Pingdom account (or by whoever knows the seed). Everything that comes
+// from the payload is escaped before it is interpolated into the Markdown of the post.
+
+// markdownEscaper backslash-escapes the characters which start the inline Markdown formatting.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"(", `\(`,
+	")", `\)`,
+	"#", `\#`,
+	">", `\>`,
+	"~", `\~`,
+	"|", `\|`,
+	"\r", " ",
+	"\n", " ",
+)
+
+// codeEscaper makes sure the value can not break out of the inline code span.
+var codeEscaper = strings.NewReplacer(
+	"`", "'",
+	"\r", " ",
+	"\n", " ",
+)
+
+// urlEscaper makes sure the URL can not break out of the Markdown link target.
+var urlEscaper = strings.NewReplacer(
+	" ", "%20",
+	"(", "%28",
+	")", "%29",
+	"<", "%3C",
+	">", "%3E",
+	"`", "%60",
+	"\r", "",
+	"\n", "",
+)
+
+// channelMentionRegexp matches the mentions which notify everybody in the channel.
+var channelMentionRegexp = regexp.MustCompile(`(?i)@(all|channel|here)\b`)
+
+// escapeMarkdown makes the untrusted value safe to be interpolated into Markdown as plain text.
+func escapeMarkdown(s string) string {
+	return neutralizeMentions(markdownEscaper.Replace(s))
+}
+
+// escapeCode makes the untrusted value safe to be wrapped into the inline code span.
+func escapeCode(s string) string {
+	return codeEscaper.Replace(s)
+}
+
+// markdownLink renders the untrusted URL as a Markdown link.
+func markdownLink(text, target string) string {
+	return "[" + escapeMarkdown(text) + "](" + urlEscaper.Replace(target) + ")"
+}
+
+// neutralizeMentions breaks @all, @channel and @here with the zero-width space, so they are
+// rendered as is but do not notify the whole channel.
+func neutralizeMentions(s string) string {
+	return channelMentionRegexp.ReplaceAllString(s, "@\u200b$1")
+}
+
+// sanitizeAttachment is the last line of defence before the attachment is posted: no matter how
+// the text had been rendered, it must not notify the whole channel.
+func sanitizeAttachment(attachment *model.SlackAttachment) {
+	attachment.Pretext = neutralizeMentions(attachment.Pretext)
+	attachment.Text = neutralizeMentions(attachment.Text)
+	attachment.Title = neutralizeMentions(attachment.Title)
+	attachment.Fallback = neutralizeMentions(attachment.Fallback)
+	for _, field := range attachment.Fields {
+		field.Title = neutralizeMentions(field.Title)
+		if value, ok := field.Value.(string); ok {
+			field.Value = neutralizeMentions(value)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEscapeMarkdown(t *testing.T) {
+	for name, tc := range map[string]struct {
+		input    string
+		expected string
+	}{
+		"plain text": {
+			input:    "www.example.com",
+			expected: "www.example.com",
+		},
+		"link": {
+			input:    "[click me](https://evil.example.com)",
+			expected: `\[click me\]\(https://evil.example.com\)`,
+		},
+		"emphasis and code": {
+			input:    "**bold** _it_ `code`",
+			expected: "\\*\\*bold\\*\\* \\_it\\_ \\`code\\`",
+		},
+		"channel-wide mentions": {
+			input:    "@all @Channel @here @here2 @john",
+			expected: "@\u200ball @\u200bChannel @\u200bhere @here2 @john",
+		},
+		"new lines": {
+			input:    "line1\n# line2",
+			expected: `line1 \# line2`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := escapeMarkdown(tc.input); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMarkdownLink(t *testing.T) {
+	got := markdownLink("https://example.com/a b", "https://example.com/a b)[x](https://evil.example.com")
+	expected := `[https://example.com/a b](https://example.com/a%20b%29[x]%28https://evil.example.com)`
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"sort"
+)
+
+var (
+	errUnknownSeed  = errors.New("invalid or missing seed")
+	errHookDisabled = errors.New("the webhook is disabled")
+	errUnknownHook  = errors.New("unknown webhook")
+)
+
+// seedIndex narrows down the hooks the webhook call may be authenticated for, so the seed of the
+// call does not have to be compared against every hook. It is built on the configuration change.
+type seedIndex struct {
+	// bySeed maps the SHA-256 of the current and the retired seeds to the IDs of the hooks having
+	// them. The seeds are hashed, so the time of the map lookup tells nothing about the seed.
+	bySeed map[[sha256.Size]byte][]string
+	// seedHeaders are the headers the hooks with the "header" AuthMethod expect the seed in.
+	seedHeaders []string
+	// hmacHooks are the IDs of the hooks with the "hmac" AuthMethod: the seed is not sent at all,
+	// so every one of them has to be tried.
+	hmacHooks []string
+}
+
+func newSeedIndex(hooks map[string]pingdomHookConfig) *seedIndex {
+	index := &seedIndex{
+		bySeed: make(map[[sha256.Size]byte][]string),
+	}
+
+	// The IDs are sorted to make the lookup deterministic when the seed is shared by the hooks.
+	ids := make([]string, 0, len(hooks))
+	for id := range hooks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	headers := make(map[string]bool)
+	for _, id := range ids {
+		hook := hooks[id]
+		if hook.Seed != "" {
+			index.add(hook.Seed, id)
+		}
+		for _, rs := range hook.RetiredSeeds {
+			if rs.Seed != "" {
+				index.add(rs.Seed, id)
+			}
+		}
+
+		switch hook.AuthMethod {
+		case authMethodHeader:
+			if header := http.CanonicalHeaderKey(hook.getAuthHeader()); !headers[header] {
+				headers[header] = true
+				index.seedHeaders = append(index.seedHeaders, header)
+			}
+		case authMethodHMAC:
+			index.hmacHooks = append(index.hmacHooks, id)
+		}
+	}
+
+	return index
+}
+
+func (si *seedIndex) add(seed, id string) {
+	sum := sha256.Sum256([]byte(seed))
+	si.bySeed[sum] = append(si.bySeed[sum], id)
+}
+
+// candidates returns the IDs of the hooks the request may be authenticated for.
+func (si *seedIndex) candidates(r *http.Request) []string {
+	var secrets []string
+	if seed := r.URL.Query().Get("seed"); seed != "" {
+		secrets = append(secrets, seed)
+	}
+	if _, password, ok := r.BasicAuth(); ok && password != "" {
+		secrets = append(secrets, password)
+	}
+	for _, header := range si.seedHeaders {
+		if seed := r.Header.Get(header); seed != "" {
+			secrets = append(secrets, seed)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	for _, secret := range secrets {
+		for _, id := range si.bySeed[sha256.Sum256([]byte(secret))] {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	for _, id := range si.hmacHooks {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// findHook returns the hook the request is authenticated for and the seed it was authenticated
+// with. errUnknownSeed is returned when no hook accepts the request and errHookDisabled (together
+// with the disabled hook) when only the disabled hooks do.
+func (c *configuration) findHook(r *http.Request, body []byte) (pingdomHookConfig, string, error) {
+	if c.seedIndex == nil {
+		return pingdomHookConfig{}, "", errUnknownSeed
+	}
+
+	var disabled *pingdomHookConfig
+	for _, id := range c.seedIndex.candidates(r) {
+		hook, ok := c.PingdomHooksConfigs[id]
+		if !ok {
+			continue
+		}
+		seed, ok := hook.authenticate(r, body)
+		if !ok {
+			continue
+		}
+		if hook.Disabled {
+			if disabled == nil {
+				disabled = &hook
+			}
+			continue
+		}
+		return hook, seed, nil
+	}
+
+	if disabled != nil {
+		return *disabled, "", errHookDisabled
+	}
+	return pingdomHookConfig{}, "", errUnknownSeed
+}
+
+// findHookByID authenticates the request for the hook addressed by its ID in the URL path.
+// errUnknownHook is returned when there is no such hook.
+func (c *configuration) findHookByID(hookID string, r *http.Request, body []byte) (pingdomHookConfig, string, error) {
+	hook, ok := c.PingdomHooksConfigs[hookID]
+	if !ok {
+		return pingdomHookConfig{}, "", errUnknownHook
+	}
+
+	seed, ok := hook.authenticate(r, body)
+	if !ok {
+		return pingdomHookConfig{}, "", errUnknownSeed
+	}
+	if hook.Disabled {
+		return hook, "", errHookDisabled
+	}
+	return hook, seed, nil
+}
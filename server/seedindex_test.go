@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFindHook(t *testing.T) {
+	body := []byte(`{"check_id":1}`)
+	sign := func(seed string) string {
+		mac := hmac.New(sha256.New, []byte(seed))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	future := time.Now().Add(time.Hour).UnixMilli()
+	past := time.Now().Add(-time.Hour).UnixMilli()
+
+	hooks := make(map[string]pingdomHookConfig)
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprint(i)
+		hooks[id] = pingdomHookConfig{ID: id, Team: "team", Channel: "channel", Seed: "seed-" + id}
+	}
+	hooks["20"] = pingdomHookConfig{ID: "20", Seed: "seed-20", Disabled: true}
+	hooks["21"] = pingdomHookConfig{ID: "21", Seed: "seed-21", AuthMethod: authMethodHeader}
+	hooks["22"] = pingdomHookConfig{ID: "22", Seed: "seed-22", AuthMethod: authMethodHeader, AuthHeader: "X-Token"}
+	hooks["23"] = pingdomHookConfig{ID: "23", Seed: "seed-23", AuthMethod: authMethodBasic, BasicUsername: "pingdom"}
+	hooks["24"] = pingdomHookConfig{ID: "24", Seed: "seed-24", AuthMethod: authMethodHMAC}
+	hooks["25"] = pingdomHookConfig{ID: "25", Seed: "seed-25", AuthMethod: authMethodHMAC}
+	hooks["26"] = pingdomHookConfig{ID: "26", Seed: "seed-26", AuthMethod: authMethodHeader, DisableQuerySeed: true}
+	hooks["27"] = pingdomHookConfig{ID: "27", Seed: "seed-27", RetiredSeeds: []retiredSeed{
+		{Seed: "old-27", ExpiresAt: future},
+		{Seed: "expired-27", ExpiresAt: past},
+	}}
+	// The seed shared by the disabled and the enabled hooks.
+	hooks["28"] = pingdomHookConfig{ID: "28", Seed: "shared", Disabled: true}
+	hooks["29"] = pingdomHookConfig{ID: "29", Seed: "shared"}
+
+	config := &configuration{PingdomHooksConfigs: hooks}
+	config.seedIndex = newSeedIndex(config.PingdomHooksConfigs)
+
+	for name, tc := range map[string]struct {
+		query        string
+		headers      map[string]string
+		username     string
+		password     string
+		expectedID   string
+		expectedSeed string
+		expectedErr  error
+	}{
+		"no credentials": {
+			expectedErr: errUnknownSeed,
+		},
+		"unknown seed": {
+			query:       "unknown",
+			expectedErr: errUnknownSeed,
+		},
+		"first hook": {
+			query:        "seed-0",
+			expectedID:   "0",
+			expectedSeed: "seed-0",
+		},
+		"last of the plain hooks": {
+			query:        "seed-19",
+			expectedID:   "19",
+			expectedSeed: "seed-19",
+		},
+		"disabled hook": {
+			query:       "seed-20",
+			expectedID:  "20",
+			expectedErr: errHookDisabled,
+		},
+		"seed of another hook in the header": {
+			headers:     map[string]string{"X-Pingdom-Seed": "seed-5"},
+			expectedErr: errUnknownSeed,
+		},
+		"default header": {
+			headers:      map[string]string{"X-Pingdom-Seed": "seed-21"},
+			expectedID:   "21",
+			expectedSeed: "seed-21",
+		},
+		"custom header": {
+			headers:      map[string]string{"X-Token": "seed-22"},
+			expectedID:   "22",
+			expectedSeed: "seed-22",
+		},
+		"basic": {
+			username:     "pingdom",
+			password:     "seed-23",
+			expectedID:   "23",
+			expectedSeed: "seed-23",
+		},
+		"hmac of the first hmac hook": {
+			headers:      map[string]string{"X-Pingdom-Signature": sign("seed-24")},
+			expectedID:   "24",
+			expectedSeed: "seed-24",
+		},
+		"hmac of the second hmac hook": {
+			headers:      map[string]string{"X-Pingdom-Signature": "sha256=" + sign("seed-25")},
+			expectedID:   "25",
+			expectedSeed: "seed-25",
+		},
+		"hmac with the unknown key": {
+			headers:     map[string]string{"X-Pingdom-Signature": sign("unknown")},
+			expectedErr: errUnknownSeed,
+		},
+		"query seed is disabled": {
+			query:       "seed-26",
+			expectedErr: errUnknownSeed,
+		},
+		"retired seed": {
+			query:        "old-27",
+			expectedID:   "27",
+			expectedSeed: "old-27",
+		},
+		"expired seed": {
+			query:       "expired-27",
+			expectedErr: errUnknownSeed,
+		},
+		"shared seed picks the enabled hook": {
+			query:        "shared",
+			expectedID:   "29",
+			expectedSeed: "shared",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			url := "/api/webhook"
+			if tc.query != "" {
+				url += "?seed=" + tc.query
+			}
+			r := httptest.NewRequest("POST", url, nil)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			if tc.username != "" {
+				r.SetBasicAuth(tc.username, tc.password)
+			}
+
+			hook, seed, err := config.findHook(r, body)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if hook.ID != tc.expectedID {
+				t.Errorf("expected hook %q, got %q", tc.expectedID, hook.ID)
+			}
+			if seed != tc.expectedSeed {
+				t.Errorf("expected seed %q, got %q", tc.expectedSeed, seed)
+			}
+		})
+	}
+}
+
+func TestSeedIndexHashesSeeds(t *testing.T) {
+	index := newSeedIndex(map[string]pingdomHookConfig{
+		"0": {ID: "0", Seed: "seed-0", RetiredSeeds: []retiredSeed{{Seed: "old-0"}}},
+	})
+	for _, seed := range []string{"seed-0", "old-0"} {
+		if ids := index.bySeed[sha256.Sum256([]byte(seed))]; len(ids) != 1 || ids[0] != "0" {
+			t.Errorf("expected the seed %q to be indexed by its hash, got %v", seed, ids)
+		}
+	}
+	if len(index.bySeed) != 2 {
+		t.Errorf("expected 2 indexed seeds, got %d", len(index.bySeed))
+	}
+}
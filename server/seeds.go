@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// defaultSeedGracePeriodHours is how long the regenerated seed keeps working when the hook does not
+// define its own SeedGracePeriodHours. The webapp sets ExpiresAt of the retired seed from it.
+const defaultSeedGracePeriodHours = 24
+
+// retiredSeedReportInterval limits how often the usage of the retired seed is reported in the channel.
+const retiredSeedReportInterval = time.Hour
+
+// retiredSeed is the previous seed of the hook which keeps working until it expires, so Pingdom can
+// be switched to the new seed without losing the alerts.
+type retiredSeed struct {
+	Seed string
+	// ExpiresAt is the Unix time in milliseconds (as the webapp produces it).
+	ExpiresAt int64
+}
+
+func (rs retiredSeed) expiresAt() time.Time {
+	return time.UnixMilli(rs.ExpiresAt)
+}
+
+// validSeeds returns the current seed followed by the retired seeds which are not expired yet.
+func (ac *pingdomHookConfig) validSeeds(now time.Time) []string {
+	seeds := []string{ac.Seed}
+	for _, rs := range ac.RetiredSeeds {
+		if rs.Seed != "" && now.Before(rs.expiresAt()) {
+			seeds = append(seeds, rs.Seed)
+		}
+	}
+	return seeds
+}
+
+// getRetiredSeed returns the retired seed entry for the seed, if any.
+func (ac *pingdomHookConfig) getRetiredSeed(seed string) (retiredSeed, bool) {
+	for _, rs := range ac.RetiredSeeds {
+		if secureEqual(rs.Seed, seed) {
+			return rs, true
+		}
+	}
+	return retiredSeed{}, false
+}
+
+// seedHint is the part of the seed which is safe to be logged and shown.
+func seedHint(seed string) string {
+	if len(seed) <= 8 {
+		return "****"
+	}
+	return fmt.Sprintf("%s...%s", seed[:4], seed[len(seed)-4:])
+}
+
+// seedFingerprint identifies the seed in the KV store without storing the seed itself.
+func seedFingerprint(seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:8])
+}
+
+// seedUsage is kept in the KV store per hook and seed, the timestamps are in milliseconds, as the
+// ExpiresAt of the retiredSeed.
+type seedUsage struct {
+	LastUsedAt     int64 `json:"last_used_at"`
+	LastReportedAt int64 `json:"last_reported_at"`
+}
+
+func seedUsageKey(hookID, seed string) string {
+	return fmt.Sprintf("seed_usage_%s_%s", hookID, seedFingerprint(seed))
+}
+
+func (p *Plugin) getSeedUsage(hookID, seed string) (seedUsage, error) {
+	var usage seedUsage
+	if err := p.client.KV.Get(seedUsageKey(hookID, seed), &usage); err != nil {
+		return usage, fmt.Errorf("failed to get the seed usage: %w", err)
+	}
+	return usage, nil
+}
+
+// recordSeedUsage remembers when the seed was used last time. When it is the retired seed, the
+// usage is logged and reported in the channel of the hook (not more often than once per
+// retiredSeedReportInterval), so the admins know Pingdom still has to be updated.
+func (p *Plugin) recordSeedUsage(config pingdomHookConfig, seed string) {
+	now := time.Now()
+	usage, err := p.getSeedUsage(config.ID, seed)
+	if err != nil {
+		p.API.LogWarn("failed to get the seed usage", "hook_id", config.ID, "err", err.Error())
+	}
+	usage.LastUsedAt = now.UnixMilli()
+
+	if rs, ok := config.getRetiredSeed(seed); ok && !secureEqual(seed, config.Seed) {
+		p.API.LogWarn("The webhook had been called with the retired seed",
+			"hook_id", config.ID, "seed", seedHint(seed), "expires_at", rs.expiresAt().UTC().Format(time.RFC3339))
+
+		if now.Sub(time.UnixMilli(usage.LastReportedAt)) >= retiredSeedReportInterval {
+			usage.LastReportedAt = now.UnixMilli()
+			p.reportRetiredSeedUsage(config, rs)
+		}
+	}
+
+	if _, err := p.client.KV.Set(seedUsageKey(config.ID, seed), usage); err != nil {
+		p.API.LogWarn("failed to save the seed usage", "hook_id", config.ID, "err", err.Error())
+	}
+}
+
+func (p *Plugin) reportRetiredSeedUsage(config pingdomHookConfig, rs retiredSeed) {
+	loc, err := config.GetLocation()
+	if err != nil {
+		loc = time.UTC
+	}
+
+	channelID, err := p.getHookChannelID(config)
+	if err != nil {
+		p.API.LogWarn("failed to report the retired seed usage", "hook_id", config.ID, "err", err.Error())
+		return
+	}
+
+	post := &model.Post{
+		ChannelId: channelID,
+		UserId:    p.BotUserID,
+		Message: fmt.Sprintf(":warning: Pingdom had called the webhook with the retired seed `%s`, which stops working at %s. "+
+			"Update the Pingdom integration with the current seed.",
+			seedHint(rs.Seed), formatTime(rs.expiresAt(), loc, config.GetTimeLayout())),
+	}
+	if _, appErr := p.API.CreatePost(post); appErr != nil {
+		p.API.LogWarn("failed to report the retired seed usage", "hook_id", config.ID, "err", appErr.Error())
+	}
+}
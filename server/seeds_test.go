@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordSeedUsage(t *testing.T) {
+	api := newFakeAPI()
+	expiresAt := time.Now().Add(time.Hour).UnixMilli()
+	config := pingdomHookConfig{ID: "0", Team: "team", Channel: "alerts", Seed: "seed-0",
+		RetiredSeeds: []retiredSeed{{Seed: "old-0", ExpiresAt: expiresAt}}}
+	p := newTestPlugin(api, &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{"0": config}})
+	p.channels.set("0", "channel-0", time.Now())
+
+	before := time.Now().UnixMilli()
+	for i := 0; i < 2; i++ {
+		p.recordSeedUsage(config, "old-0")
+	}
+
+	usage, err := p.getSeedUsage("0", "old-0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The usage is in milliseconds, as the expiration of the retired seed.
+	if usage.LastUsedAt < before || usage.LastUsedAt > time.Now().UnixMilli() {
+		t.Errorf("expected the last use in milliseconds, got %d", usage.LastUsedAt)
+	}
+	if usage.LastReportedAt < before || usage.LastReportedAt > usage.LastUsedAt {
+		t.Errorf("expected the last report in milliseconds, got %d", usage.LastReportedAt)
+	}
+	if len(api.posts) != 1 {
+		t.Errorf("expected the retired seed usage to be reported once, got %d posts", len(api.posts))
+	}
+
+	if usage, err = p.getSeedUsage("0", "seed-0"); err != nil || usage.LastUsedAt != 0 {
+		t.Errorf("expected no usage of the current seed, got %+v, %v", usage, err)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// hookStats tells whether the hook is actually called and how its alerts are delivered. The
+// timestamps are in milliseconds.
+type hookStats struct {
+	HookID         string `json:"hook_id"`
+	Received       int64  `json:"received"`
+	Rejected       int64  `json:"rejected"`
+	Delivered      int64  `json:"delivered"`
+	Failures       int64  `json:"failures"`
+	LastReceivedAt int64  `json:"last_received_at,omitempty"`
+	LastCheckName  string `json:"last_check_name,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	LastErrorAt    int64  `json:"last_error_at,omitempty"`
+}
+
+func hookStatsKey(hookID string) string {
+	return fmt.Sprintf("hook_stats_%s", hookID)
+}
+
+// merge adds the counters of the delta, the latest alert and error win.
+func (s *hookStats) merge(delta *hookStats) {
+	s.Received += delta.Received
+	s.Rejected += delta.Rejected
+	s.Delivered += delta.Delivered
+	s.Failures += delta.Failures
+	if delta.LastReceivedAt > s.LastReceivedAt {
+		s.LastReceivedAt = delta.LastReceivedAt
+		s.LastCheckName = delta.LastCheckName
+	}
+	if delta.LastErrorAt > s.LastErrorAt {
+		s.LastErrorAt = delta.LastErrorAt
+		s.LastError = delta.LastError
+	}
+}
+
+// hookStatsBuffer collects the stats of the node in memory, they are merged into the KV store by
+// flushHookStats. The webhook calls (and the floods of the rejected ones) do not write the KV store.
+type hookStatsBuffer struct {
+	lock    sync.Mutex
+	pending map[string]*hookStats
+}
+
+func (b *hookStatsBuffer) update(hookID string, update func(*hookStats)) {
+	if hookID == "" {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.pending == nil {
+		b.pending = make(map[string]*hookStats)
+	}
+	stats, ok := b.pending[hookID]
+	if !ok {
+		stats = &hookStats{HookID: hookID}
+		b.pending[hookID] = stats
+	}
+	update(stats)
+}
+
+// take returns the collected stats and starts collecting from scratch.
+func (b *hookStatsBuffer) take() map[string]*hookStats {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	pending := b.pending
+	b.pending = nil
+	return pending
+}
+
+func (p *Plugin) recordAlertReceived(hookID, checkName string) {
+	p.stats.update(hookID, func(s *hookStats) {
+		s.Received++
+		s.LastReceivedAt = time.Now().UnixMilli()
+		s.LastCheckName = checkName
+	})
+}
+
+func (p *Plugin) recordAlertRejected(hookID, reason string) {
+	p.stats.update(hookID, func(s *hookStats) {
+		s.Rejected++
+		s.LastError = reason
+		s.LastErrorAt = time.Now().UnixMilli()
+	})
+}
+
+func (p *Plugin) recordAlertDelivered(hookID string) {
+	p.stats.update(hookID, func(s *hookStats) {
+		s.Delivered++
+	})
+}
+
+func (p *Plugin) recordDeliveryFailure(hookID string, err error) {
+	p.stats.update(hookID, func(s *hookStats) {
+		s.Failures++
+		s.LastError = err.Error()
+		s.LastErrorAt = time.Now().UnixMilli()
+	})
+}
+
+// flushHookStats merges the stats collected by the node into the KV store. The stats which fail to
+// be stored are kept for the next flush.
+func (p *Plugin) flushHookStats() {
+	for hookID, delta := range p.stats.take() {
+		err := p.client.KV.SetAtomicWithRetries(hookStatsKey(hookID), func(oldValue []byte) (interface{}, error) {
+			stats := hookStats{HookID: hookID}
+			if len(oldValue) > 0 {
+				if err := json.Unmarshal(oldValue, &stats); err != nil {
+					return nil, err
+				}
+			}
+			stats.merge(delta)
+			return stats, nil
+		})
+		if err != nil {
+			p.API.LogWarn("failed to store the hook stats", "hook_id", hookID, "err", err.Error())
+			p.stats.update(hookID, func(s *hookStats) {
+				s.merge(delta)
+			})
+		}
+	}
+}
+
+// getHookStats returns the stats of the hook stored in the KV store, the ones which are not flushed
+// yet are not included.
+func (p *Plugin) getHookStats(hookID string) (hookStats, error) {
+	stats := hookStats{HookID: hookID}
+	var stored *hookStats
+	if err := p.client.KV.Get(hookStatsKey(hookID), &stored); err != nil {
+		return stats, fmt.Errorf("failed to get the hook stats: %w", err)
+	}
+	if stored != nil {
+		stats = *stored
+	}
+	return stats, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHookStatsMerge(t *testing.T) {
+	for name, tc := range map[string]struct {
+		stored   hookStats
+		delta    hookStats
+		expected hookStats
+	}{
+		"first stats": {
+			stored:   hookStats{HookID: "0"},
+			delta:    hookStats{Received: 2, Delivered: 1, LastReceivedAt: 2000, LastCheckName: "web"},
+			expected: hookStats{HookID: "0", Received: 2, Delivered: 1, LastReceivedAt: 2000, LastCheckName: "web"},
+		},
+		"newer alert and error win": {
+			stored:   hookStats{HookID: "0", Received: 5, Failures: 1, LastReceivedAt: 1000, LastCheckName: "old", LastErrorAt: 1000, LastError: "old"},
+			delta:    hookStats{Received: 1, Rejected: 3, LastReceivedAt: 2000, LastCheckName: "new", LastErrorAt: 3000, LastError: "new"},
+			expected: hookStats{HookID: "0", Received: 6, Rejected: 3, Failures: 1, LastReceivedAt: 2000, LastCheckName: "new", LastErrorAt: 3000, LastError: "new"},
+		},
+		"older alert of another node is counted only": {
+			stored:   hookStats{HookID: "0", Received: 5, LastReceivedAt: 2000, LastCheckName: "new"},
+			delta:    hookStats{Received: 1, LastReceivedAt: 1000, LastCheckName: "old"},
+			expected: hookStats{HookID: "0", Received: 6, LastReceivedAt: 2000, LastCheckName: "new"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stats := tc.stored
+			stats.merge(&tc.delta)
+			if !reflect.DeepEqual(stats, tc.expected) {
+				t.Errorf("expected %+v, got %+v", tc.expected, stats)
+			}
+		})
+	}
+}
+
+func TestHookStatsBuffer(t *testing.T) {
+	p := &Plugin{}
+	p.recordAlertReceived("0", "web")
+	p.recordAlertReceived("0", "api")
+	p.recordAlertDelivered("0")
+	p.recordDeliveryFailure("1", errors.New("channel is archived"))
+	p.recordAlertRejected("", "Invalid or missing seed")
+
+	pending := p.stats.take()
+	if len(pending) != 2 {
+		t.Fatalf("expected the stats of 2 hooks, got %d", len(pending))
+	}
+	if s := pending["0"]; s.Received != 2 || s.Delivered != 1 || s.LastCheckName != "api" {
+		t.Errorf("unexpected stats of the hook 0: %+v", s)
+	}
+	if s := pending["1"]; s.Failures != 1 || s.LastError != "channel is archived" || s.LastErrorAt == 0 {
+		t.Errorf("unexpected stats of the hook 1: %+v", s)
+	}
+	if pending := p.stats.take(); len(pending) != 0 {
+		t.Errorf("expected the buffer to be empty after take, got %d", len(pending))
+	}
+}
@@ -0,0 +1,98 @@
+// Package statuscake adapts the StatusCake webhook alerts to the provider-neutral model.
+// Ref.: https://www.statuscake.com/kb/knowledge-base/how-to-use-the-web-hook-url/
+package statuscake
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+// testURL is the page of the test in the StatusCake app.
+const testURL = "https://app.statuscake.com/UptimeStatus.php?tid=%d"
+
+// DecodeAlert is the uptime.Decoder of the StatusCake webhooks, they are posted form-encoded with the
+// URL, Name, Status, StatusCode, IP, Tags and CheckRate fields (and the TestID by the newer accounts).
+// StatusCake sends no timestamp, the time the alert is received is used instead.
+func DecodeAlert(body []byte, contentType string, receivedAt time.Time) (uptime.Alert, error) {
+	fields, err := uptime.ParseFields(body, contentType)
+	if err != nil {
+		return uptime.Alert{}, err
+	}
+
+	name, monitoredURL := fields.Get("Name"), fields.Get("URL")
+	if name == "" && monitoredURL == "" {
+		return uptime.Alert{}, errors.New("missing Name and URL")
+	}
+	if name == "" {
+		name = monitoredURL
+	}
+
+	a := uptime.Alert{
+		Provider:              uptime.ProviderStatusCake,
+		CheckName:             name,
+		MonitoredURL:          monitoredURL,
+		StateChangedTimestamp: receivedAt.Unix(),
+	}
+
+	if testID := fields.Get("TestID"); testID != "" {
+		if a.CheckID, err = strconv.ParseUint(testID, 10, 64); err != nil {
+			return uptime.Alert{}, fmt.Errorf("invalid TestID %q", testID)
+		}
+		a.ReportURL = fmt.Sprintf(testURL, a.CheckID)
+	} else {
+		a.CheckID = checkIDFromURL(monitoredURL, name)
+	}
+
+	switch strings.ToUpper(fields.Get("Status")) {
+	case uptime.StateUp:
+		a.CurrentState, a.PreviousState = uptime.StateUp, uptime.StateDown
+	case uptime.StateDown:
+		a.CurrentState, a.PreviousState = uptime.StateDown, uptime.StateUp
+	default:
+		a.CurrentState = strings.ToUpper(fields.Get("Status"))
+	}
+
+	if statusCode := fields.Get("StatusCode"); statusCode != "" {
+		a.Description = fmt.Sprintf("Status code %s", statusCode)
+	}
+
+	for _, tag := range strings.Split(fields.Get("Tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			a.Tags = append(a.Tags, tag)
+		}
+	}
+
+	params := make(map[string]interface{})
+	for key, name := range map[string]string{
+		"URL":        "url",
+		"StatusCode": "status_code",
+		"IP":         "ip",
+		"CheckRate":  "check_rate_seconds",
+	} {
+		if value := fields.Get(key); value != "" {
+			params[name] = value
+		}
+	}
+	if len(params) > 0 {
+		a.CheckParams = params
+	}
+	return a, nil
+}
+
+// checkIDFromURL derives the stable check ID for the accounts which do not send the TestID. It fits
+// into 53 bits, so it survives the JavaScript numbers of the webapp.
+func checkIDFromURL(monitoredURL, name string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(monitoredURL + "\x00" + name))
+	id := h.Sum64() & (1<<53 - 1)
+	if id == 0 {
+		id = 1
+	}
+	return id
+}
@@ -0,0 +1,103 @@
+package statuscake
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+const formContentType = "application/x-www-form-urlencoded"
+
+func TestDecodeAlert(t *testing.T) {
+	receivedAt := time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		payload          string
+		expectedState    string
+		expectedPrevious string
+	}{
+		"down":           {payload: "TestID=42&Name=example.com&URL=https%3A%2F%2Fexample.com&Status=Down&StatusCode=503", expectedState: uptime.StateDown, expectedPrevious: uptime.StateUp},
+		"up":             {payload: "TestID=42&Name=example.com&URL=https%3A%2F%2Fexample.com&Status=Up&StatusCode=200", expectedState: uptime.StateUp, expectedPrevious: uptime.StateDown},
+		"lowercase":      {payload: "TestID=42&Name=example.com&Status=down", expectedState: uptime.StateDown, expectedPrevious: uptime.StateUp},
+		"unknown status": {payload: "TestID=42&Name=example.com&Status=Paused", expectedState: "PAUSED"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			alert, err := DecodeAlert([]byte(tc.payload), formContentType, receivedAt)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if alert.Provider != uptime.ProviderStatusCake || alert.CheckID != 42 || alert.CheckName != "example.com" {
+				t.Errorf("unexpected check: %+v", alert)
+			}
+			if alert.CurrentState != tc.expectedState || alert.PreviousState != tc.expectedPrevious {
+				t.Errorf("expected %q -> %q, got %q -> %q", tc.expectedPrevious, tc.expectedState, alert.PreviousState, alert.CurrentState)
+			}
+			if alert.StateChangedTimestamp != receivedAt.Unix() {
+				t.Errorf("expected the receive time as the state change time, got %d", alert.StateChangedTimestamp)
+			}
+			if alert.ReportURL != "https://app.statuscake.com/UptimeStatus.php?tid=42" {
+				t.Errorf("unexpected report URL %s", alert.ReportURL)
+			}
+		})
+	}
+}
+
+func TestDecodeAlertFields(t *testing.T) {
+	payload := "Name=&URL=https%3A%2F%2Fexample.com&Status=Down&StatusCode=503&Tags=web%2C+prod%2C&IP=192.0.2.1"
+	alert, err := DecodeAlert([]byte(payload), formContentType, time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if alert.CheckName != "https://example.com" {
+		t.Errorf("expected the URL as the check name, got %q", alert.CheckName)
+	}
+	if alert.Description != "Status code 503" {
+		t.Errorf("unexpected description %q", alert.Description)
+	}
+	if len(alert.Tags) != 2 || alert.Tags[0] != "web" || alert.Tags[1] != "prod" {
+		t.Errorf("expected the trimmed tags, got %q", alert.Tags)
+	}
+	for key, expected := range map[string]string{
+		"url":         "https://example.com",
+		"status_code": "503",
+		"ip":          "192.0.2.1",
+	} {
+		if alert.CheckParams[key] != expected {
+			t.Errorf("expected the check param %s to be %q, got %v", key, expected, alert.CheckParams[key])
+		}
+	}
+
+	// The accounts without the TestID get the ID derived from the URL and the name.
+	again, err := DecodeAlert([]byte(payload), formContentType, time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if alert.CheckID == 0 || alert.CheckID >= 1<<53 || again.CheckID != alert.CheckID {
+		t.Errorf("expected the stable check ID within 53 bits, got %d and %d", alert.CheckID, again.CheckID)
+	}
+	if alert.ReportURL != "" {
+		t.Errorf("expected no report URL without the TestID, got %s", alert.ReportURL)
+	}
+}
+
+func TestDecodeAlertMalformed(t *testing.T) {
+	for name, tc := range map[string]struct {
+		payload     string
+		contentType string
+	}{
+		"missing name and URL": {payload: "Status=Down&StatusCode=503", contentType: formContentType},
+		"invalid test ID":      {payload: "TestID=abc&Name=example.com&Status=Down", contentType: formContentType},
+		"invalid form":         {payload: "Name=%zz", contentType: formContentType},
+		"invalid JSON":         {payload: `{"Name": "example.com"`, contentType: "application/json"},
+		"not an object":        {payload: `"example.com"`, contentType: "application/json"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecodeAlert([]byte(tc.payload), tc.contentType, time.Now()); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
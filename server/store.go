@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	pluginapi "github.com/mattermost/mattermost/server/public/pluginapi"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+// alertIdempotencyTTL is how long the delivered alert is remembered. Pingdom retries the webhook
+// within minutes, the day covers the retries with a large margin.
+const alertIdempotencyTTL = 24 * time.Hour
+
+// checkState is the latest known state of the Pingdom check, it is kept in the KV store.
+type checkState struct {
+	HookID        string `json:"hook_id"`
+	CheckID       uint64 `json:"check_id"`
+	CheckName     string `json:"check_name"`
+	State         string `json:"state"`
+	PreviousState string `json:"previous_state"`
+	ChangedAt     int64  `json:"changed_at"`
+	ReceivedAt    int64  `json:"received_at"`
+	PostID        string `json:"post_id,omitempty"`
+}
+
+// checkStateKeysPrefix starts the keys of the check states of every hook.
+const checkStateKeysPrefix = "check_state_"
+
+func checkStateKeyPrefix(hookID string) string {
+	return fmt.Sprintf("%s%s_", checkStateKeysPrefix, hookID)
+}
+
+func checkStateKey(hookID string, checkID uint64) string {
+	return fmt.Sprintf("%s%d", checkStateKeyPrefix(hookID), checkID)
+}
+
+// saveCheckState records the state the check had been changed to.
+func (p *Plugin) saveCheckState(hookID string, message uptime.Alert, postID string) error {
+	state := checkState{
+		HookID:        hookID,
+		CheckID:       message.CheckID,
+		CheckName:     message.CheckName,
+		State:         message.CurrentState,
+		PreviousState: message.PreviousState,
+		ChangedAt:     message.StateChangedTimestamp,
+		ReceivedAt:    time.Now().Unix(),
+		PostID:        postID,
+	}
+	if _, err := p.client.KV.Set(checkStateKey(hookID, message.CheckID), state); err != nil {
+		return fmt.Errorf("failed to save the check state: %w", err)
+	}
+	return nil
+}
+
+// getCheckState returns the latest known state of the check or nil if nothing had been recorded.
+func (p *Plugin) getCheckState(hookID string, checkID uint64) (*checkState, error) {
+	var state *checkState
+	if err := p.client.KV.Get(checkStateKey(hookID, checkID), &state); err != nil {
+		return nil, fmt.Errorf("failed to get the check state: %w", err)
+	}
+	return state, nil
+}
+
+// listKeys returns every KV key with the prefix. ListKeys filters the page after it is fetched, so
+// the pages are read until the unfiltered page is short.
+func (p *Plugin) listKeys(prefix string) ([]string, error) {
+	const perPage = 1000
+
+	var keys []string
+	for page := 0; ; page++ {
+		pageKeys, err := p.client.KV.ListKeys(page, perPage)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range pageKeys {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		if len(pageKeys) < perPage {
+			return keys, nil
+		}
+	}
+}
+
+// listCheckStates returns the latest known states of the checks the hook had received the alerts
+// of, by the check ID.
+func (p *Plugin) listCheckStates(hookID string) (map[uint64]*checkState, error) {
+	keys, err := p.listKeys(checkStateKeyPrefix(hookID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the check states: %w", err)
+	}
+
+	states := make(map[uint64]*checkState)
+	for _, key := range keys {
+		var state *checkState
+		if err := p.client.KV.Get(key, &state); err != nil {
+			return nil, fmt.Errorf("failed to get the check state: %w", err)
+		}
+		// The prefix of the hook "1" matches the keys of the hook "1_2" as well.
+		if state != nil && state.HookID == hookID {
+			states[state.CheckID] = state
+		}
+	}
+	return states, nil
+}
+
+// alertIdempotencyKey identifies the state change of the check. The components are hashed, as the
+// KV keys are limited in length and the hook ID is arbitrary. The legacy alerts have no state change
+// timestamp, their incident ID is used instead.
+func alertIdempotencyKey(hookID string, message uptime.Alert) string {
+	changed := strconv.FormatInt(message.StateChangedTimestamp, 10)
+	if message.IncidentID != 0 {
+		changed = fmt.Sprintf("incident-%d", message.IncidentID)
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%s\x00%s",
+		hookID, message.CheckID, message.CurrentState, changed)))
+	return "alert_seen_" + hex.EncodeToString(sum[:16])
+}
+
+// claimAlert records the state change of the check, it returns false if it had been recorded
+// already. The write is atomic, so only one node of the cluster posts the alert.
+func (p *Plugin) claimAlert(hookID string, message uptime.Alert) (bool, error) {
+	claimed, err := p.client.KV.Set(alertIdempotencyKey(hookID, message), time.Now().Unix(),
+		pluginapi.SetAtomic(nil), pluginapi.SetExpiry(alertIdempotencyTTL))
+	if err != nil {
+		return false, fmt.Errorf("failed to record the alert: %w", err)
+	}
+	return claimed, nil
+}
+
+// releaseAlert forgets the state change of the check, so the retry of the failed delivery is posted.
+func (p *Plugin) releaseAlert(hookID string, message uptime.Alert) error {
+	if err := p.client.KV.Delete(alertIdempotencyKey(hookID, message)); err != nil {
+		return fmt.Errorf("failed to release the alert: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+func TestAlertIdempotencyKey(t *testing.T) {
+	changedAt := int64(1700000000)
+	message := uptime.Alert{CheckID: 1, CurrentState: "DOWN", StateChangedTimestamp: changedAt}
+	key := alertIdempotencyKey("0", message)
+
+	if len(key) > 150 {
+		t.Errorf("expected the key to fit the KV store, got %d characters", len(key))
+	}
+	if alertIdempotencyKey("0", message) != key {
+		t.Errorf("expected the same key for the repeated alert")
+	}
+
+	other := message
+	other.CheckName = "renamed"
+	if alertIdempotencyKey("0", other) != key {
+		t.Errorf("expected the check name not to affect the key")
+	}
+
+	for name, changed := range map[string]uptime.Alert{
+		"check":      {CheckID: 2, CurrentState: "DOWN", StateChangedTimestamp: changedAt},
+		"state":      {CheckID: 1, CurrentState: "UP", StateChangedTimestamp: changedAt},
+		"changed at": {CheckID: 1, CurrentState: "DOWN", StateChangedTimestamp: changedAt + 1},
+		"incident":   {CheckID: 1, CurrentState: "DOWN", StateChangedTimestamp: changedAt, IncidentID: 1},
+	} {
+		if alertIdempotencyKey("0", changed) == key {
+			t.Errorf("expected another key for the changed %s", name)
+		}
+	}
+	if alertIdempotencyKey("1", message) == key {
+		t.Errorf("expected another key for another hook")
+	}
+}
+
+func TestCheckStateStore(t *testing.T) {
+	p := newTestPlugin(newFakeAPI(), &configuration{})
+
+	down := uptime.Alert{CheckID: 1, CheckName: "web", CurrentState: "DOWN", PreviousState: "UP", StateChangedTimestamp: 1700000000}
+	for hookID, message := range map[string]uptime.Alert{
+		"1":   down,
+		"1_2": {CheckID: 2, CheckName: "api", CurrentState: "UP", PreviousState: "DOWN", StateChangedTimestamp: 1700000300},
+	} {
+		if err := p.saveCheckState(hookID, message, "post-"+hookID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	state, err := p.getCheckState("1", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state == nil || state.HookID != "1" || state.CheckName != "web" || state.State != "DOWN" ||
+		state.PreviousState != "UP" || state.ChangedAt != 1700000000 || state.PostID != "post-1" {
+		t.Errorf("unexpected check state: %+v", state)
+	}
+
+	if state, err = p.getCheckState("1", 2); err != nil || state != nil {
+		t.Errorf("expected no state of the unknown check, got %+v, %v", state, err)
+	}
+
+	states, err := p.listCheckStates("1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(states) != 1 || states[1] == nil {
+		t.Errorf("expected the check 1 of the hook 1 only, got %+v", states)
+	}
+}
+
+func TestClaimAlert(t *testing.T) {
+	p := newTestPlugin(newFakeAPI(), &configuration{})
+	message := uptime.Alert{CheckID: 1, CurrentState: "DOWN", StateChangedTimestamp: 1700000000}
+
+	for i, expected := range []bool{true, false} {
+		claimed, err := p.claimAlert("0", message)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if claimed != expected {
+			t.Errorf("claim %d: expected %v, got %v", i, expected, claimed)
+		}
+	}
+
+	if err := p.releaseAlert("0", message); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if claimed, err := p.claimAlert("0", message); err != nil || !claimed {
+		t.Errorf("expected the released alert to be claimed again, got %v, %v", claimed, err)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+)
+
+// testCheckID is the ID of the synthetic check of the test alerts, it is far above the IDs Pingdom hands out.
+const testCheckID = 9000000001
+
+// testAlertContextKey marks the requests of the test alerts, so the synthetic check is not recorded
+// as the real one. The external webhook calls can not set it.
+type testAlertContextKey struct{}
+
+// isTestAlertRequest tells if the webhook request carries the test alert.
+func isTestAlertRequest(r *http.Request) bool {
+	test, _ := r.Context().Value(testAlertContextKey{}).(bool)
+	return test
+}
+
+// testCheckParams are the realistic check_params of the test alerts per check type.
+var testCheckParams = map[string]pingdom.KV{
+	pingdom.CheckTypeHTTP: {
+		"hostname": "www.example.com", "port": 443, "url": "/", "full_url": "https://www.example.com/",
+		"encryption": true, "verify_certificate": true, "ipv6": false, "basic_auth": false,
+		"header": "User-Agent:Pingdom.com_bot_version_1.4_(http://www.pingdom.com/)", "shouldcontain": "Example Domain",
+	},
+	pingdom.CheckTypeHTTPCustom: {
+		"hostname": "www.example.com", "port": 443, "url": "/status.xml", "full_url": "https://www.example.com/status.xml",
+		"encryption": true, "ipv6": false, "basic_auth": false, "additional_urls": []string{"www.example.com/health"},
+	},
+	pingdom.CheckTypeDNS: {
+		"hostname": "www.example.com", "expected_ip": "93.184.216.34", "nameserver": "a.iana-servers.net", "ipv6": false,
+	},
+	pingdom.CheckTypeTCP: {
+		"hostname": "ssh.example.com", "port": 22, "ipv6": false, "stringtosend": "", "stringtoexpect": "SSH-2.0",
+	},
+	pingdom.CheckTypeUDP: {
+		"hostname": "ntp.example.com", "port": 123, "ipv6": false, "stringtosend": "ping", "stringtoexpect": "pong",
+	},
+	pingdom.CheckTypeIMAP: {
+		"hostname": "mail.example.com", "port": 993, "encryption": true, "ipv6": false, "stringtoexpect": "* OK",
+	},
+	pingdom.CheckTypePOP3: {
+		"hostname": "mail.example.com", "port": 995, "encryption": true, "ipv6": false, "stringtoexpect": "+OK",
+	},
+	pingdom.CheckTypeSMTP: {
+		"hostname": "mail.example.com", "port": 587, "encryption": true, "ipv6": false, "stringtoexpect": "220",
+	},
+	pingdom.CheckTypePing: {
+		"hostname": "www.example.com", "ipv6": false,
+	},
+	pingdom.CheckTypeTransaction: {
+		"full_url": "https://shop.example.com/checkout", "url": "/checkout", "port": 443, "encryption": true,
+	},
+}
+
+// testCheckTypes returns the check types the test alert can be sent for.
+func testCheckTypes() []string {
+	types := make([]string, 0, len(testCheckParams))
+	for checkType := range testCheckParams {
+		types = append(types, checkType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// newTestMessage builds the realistic Pingdom alert, which is clearly labelled as the test one.
+func newTestMessage(checkType, state, sentBy string, now time.Time) (pingdom.PingdomCheckMessage, error) {
+	checkType, state = strings.ToUpper(checkType), strings.ToUpper(state)
+	params, ok := testCheckParams[checkType]
+	if !ok {
+		return pingdom.PingdomCheckMessage{}, fmt.Errorf("unknown check type %q, use one of %s", checkType, strings.Join(testCheckTypes(), ", "))
+	}
+
+	previous := "UP"
+	description, longDescription := "Test alert: the check is down", "This is a test alert, the check is not really down."
+	switch state {
+	case "DOWN":
+	case "UP":
+		previous = "DOWN"
+		description, longDescription = "Test alert: the check is up", "This is a test alert, the check had not really been down."
+	default:
+		return pingdom.PingdomCheckMessage{}, fmt.Errorf("unknown state %q, use DOWN or UP", state)
+	}
+
+	return pingdom.PingdomCheckMessage{
+		CheckID:               testCheckID,
+		CheckName:             fmt.Sprintf("[TEST] %s check", checkType),
+		CheckType:             checkType,
+		CheckParams:           params,
+		Tags:                  []string{"test"},
+		PreviousState:         previous,
+		CurrentState:          state,
+		ImportanceLevel:       "HIGH",
+		StateChangedTimestamp: pingdom.UnixTime{Time: now.Truncate(time.Second)},
+		StateChangedUTCTime:   pingdom.TimeString{Time: now.UTC().Truncate(time.Second)},
+		Description:           description,
+		LongDescription:       fmt.Sprintf("%s Sent by %s.", longDescription, sentBy),
+		FirstProbe:            &pingdom.FirstProbe{IP: "185.180.12.65", IPV6: "2a02:6ea0:c035::12", Location: "Stockholm, Sweden"},
+		SecondProbe: &pingdom.SecondProbe{
+			FirstProbe: &pingdom.FirstProbe{IP: "185.152.65.167", IPV6: "2a02:6ea0:c305::12", Location: "Frankfurt, Germany"},
+			Version:    1,
+		},
+	}, nil
+}
+
+// sendTestAlert runs the test alert through the full webhook pipeline of the hook and returns the
+// HTTP status the webhook responded with. The test alerts are Pingdom ones whatever the provider
+// of the hook is.
+func (p *Plugin) sendTestAlert(config pingdomHookConfig, checkType, state, sentBy string) (int, error) {
+	message, err := newTestMessage(checkType, state, sentBy, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal the test alert: %w", err)
+	}
+
+	ctx := context.WithValue(context.Background(), testAlertContextKey{}, true)
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/api/webhook/"+config.ID, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create the test request: %w", err)
+	}
+	r.Header.Set("Content-Type", "application/json")
+
+	config.Provider = ""
+	w := httptest.NewRecorder()
+	p.handleWebhook(w, r, config)
+	if w.Code >= http.StatusBadRequest {
+		return w.Code, fmt.Errorf("the webhook responded with %d: %s", w.Code, strings.TrimSpace(w.Body.String()))
+	}
+	return w.Code, nil
+}
+
+// findTestHook returns the hook the test alert is sent through: the given one, the one posting to
+// the channel the command is run in or the only configured one.
+func (p *Plugin) findTestHook(hookID, channelID string) (pingdomHookConfig, error) {
+	hooks := p.getConfiguration().PingdomHooksConfigs
+	if hookID != "" {
+		config, ok := hooks[hookID]
+		if !ok {
+			return pingdomHookConfig{}, fmt.Errorf("the hook %q is not found", hookID)
+		}
+		return config, nil
+	}
+
+	for _, id := range p.channels.hooksOf(channelID) {
+		if config, ok := hooks[id]; ok {
+			return config, nil
+		}
+	}
+	if len(hooks) == 1 {
+		for _, config := range hooks {
+			return config, nil
+		}
+	}
+
+	ids := make([]string, 0, len(hooks))
+	for id := range hooks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if len(ids) == 0 {
+		return pingdomHookConfig{}, fmt.Errorf("no hooks are configured")
+	}
+	return pingdomHookConfig{}, fmt.Errorf("please specify the hook, one of %s", strings.Join(ids, ", "))
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTestMessage(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 30, 45, 500, time.UTC)
+
+	for name, tc := range map[string]struct {
+		checkType string
+		state     string
+		previous  string
+		wantErr   bool
+	}{
+		"down HTTP": {
+			checkType: "HTTP",
+			state:     "DOWN",
+			previous:  "UP",
+		},
+		"up DNS in lower case": {
+			checkType: "dns",
+			state:     "up",
+			previous:  "DOWN",
+		},
+		"unknown check type": {
+			checkType: "GOPHER",
+			state:     "DOWN",
+			wantErr:   true,
+		},
+		"unknown state": {
+			checkType: "HTTP",
+			state:     "SIDEWAYS",
+			wantErr:   true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			message, err := newTestMessage(tc.checkType, tc.state, "@admin", now)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.HasPrefix(message.CheckName, "[TEST]") {
+				t.Errorf("expected the check name to be labelled as the test, got %q", message.CheckName)
+			}
+			if message.CheckType != strings.ToUpper(tc.checkType) || message.CurrentState != strings.ToUpper(tc.state) || message.PreviousState != tc.previous {
+				t.Errorf("unexpected type or states: %s %s → %s", message.CheckType, message.PreviousState, message.CurrentState)
+			}
+			if !strings.Contains(message.LongDescription, "@admin") {
+				t.Errorf("expected the sender in the description, got %q", message.LongDescription)
+			}
+			if message.StateChangedTimestamp.Unix() != now.Unix() {
+				t.Errorf("expected the timestamp %d, got %d", now.Unix(), message.StateChangedTimestamp.Unix())
+			}
+			if alert := message.ToAlert(); alert.CheckID != testCheckID {
+				t.Errorf("expected the check ID %d, got %d", testCheckID, alert.CheckID)
+			}
+		})
+	}
+}
+
+func TestSendTestAlert(t *testing.T) {
+	api := newFakeAPI()
+	api.admins["admin"] = true
+	config := pingdomHookConfig{ID: "0", Team: "team", Channel: "alerts", Seed: "seed-0"}
+	p := newTestPlugin(api, &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{"0": config}})
+	p.channels.set("0", "channel-0", time.Now())
+
+	for name, tc := range map[string]struct {
+		userID   string
+		body     string
+		expected int
+	}{
+		"not an admin": {
+			userID:   "member",
+			body:     `{"hook_id":"0"}`,
+			expected: http.StatusForbidden,
+		},
+		"unknown hook": {
+			userID:   "admin",
+			body:     `{"hook_id":"1"}`,
+			expected: http.StatusNotFound,
+		},
+		"unknown state": {
+			userID:   "admin",
+			body:     `{"hook_id":"0","state":"SIDEWAYS"}`,
+			expected: http.StatusBadRequest,
+		},
+		"accepted": {
+			userID:   "admin",
+			body:     `{"hook_id":"0","state":"DOWN","type":"DNS"}`,
+			expected: http.StatusOK,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/hooks/test", strings.NewReader(tc.body))
+			r.Header.Set("Mattermost-User-ID", tc.userID)
+
+			p.ServeHTTP(nil, w, r)
+
+			if w.Code != tc.expected {
+				t.Errorf("expected status %d, got %d: %s", tc.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The test alert is queued and posted, but the synthetic check is neither recorded in the
+	// history nor counted as received by the hook.
+	keys, err := p.listQueuedAlerts()
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("expected the queued test alert, got %v, %v", keys, err)
+	}
+	var item *queuedAlert
+	if err = p.client.KV.Get(keys[0], &item); err != nil || item == nil || !item.Message.Test {
+		t.Fatalf("expected the queued alert to be the test one, got %+v, %v", item, err)
+	}
+	if _, err = p.deliverAlert(config, item.Message); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(api.posts) != 1 {
+		t.Errorf("expected the test alert to be posted, got %d posts", len(api.posts))
+	}
+	for key := range api.kv {
+		if strings.HasPrefix(key, "history_") || strings.HasPrefix(key, checkStateKeyPrefix("0")) {
+			t.Errorf("expected the test alert not to be recorded, got the key %s", key)
+		}
+	}
+	if stats := p.stats.take()["0"]; stats == nil || stats.Received != 0 || stats.Delivered != 1 {
+		t.Errorf("expected the delivered test alert not to be counted as received, got %+v", stats)
+	}
+}
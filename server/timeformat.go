@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultTimeFormat is used when the hook does not define its own TimeFormat.
+const defaultTimeFormat = time.RFC1123
+
+// namedTimeFormats lets the admins use the well-known names instead of the raw Go layouts.
+var namedTimeFormats = map[string]string{
+	"ANSIC":    time.ANSIC,
+	"UnixDate": time.UnixDate,
+	"RFC822":   time.RFC822,
+	"RFC822Z":  time.RFC822Z,
+	"RFC850":   time.RFC850,
+	"RFC1123":  time.RFC1123,
+	"RFC1123Z": time.RFC1123Z,
+	"RFC3339":  time.RFC3339,
+	"Kitchen":  time.Kitchen,
+	"DateTime": time.DateTime,
+}
+
+// timeLayout resolves the configured time format into the Go time layout.
+func timeLayout(format string) string {
+	if format == "" {
+		return defaultTimeFormat
+	}
+	if layout, ok := namedTimeFormats[format]; ok {
+		return layout
+	}
+	return format
+}
+
+// formatTime renders t in the given location and layout, e.g. "Mon, 02 Jan 2006 17:04:05 EET". The posts keep
+// the text forever, so the relative time is left to the webapp.
+func formatTime(t time.Time, loc *time.Location, layout string) string {
+	if t.IsZero() {
+		return "n/a"
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(layout)
+}
+
+// formatTimeAgo renders t like formatTime followed by the relative time, e.g.
+// "Mon, 02 Jan 2006 17:04:05 EET (3 minutes ago)". It is meant for the ephemeral command responses only.
+func formatTimeAgo(t time.Time, loc *time.Location, layout string, now time.Time) string {
+	if t.IsZero() {
+		return "n/a"
+	}
+	return fmt.Sprintf("%s (%s)", formatTime(t, loc, layout), relativeTime(t, now))
+}
+
+// relativeTime returns the human-readable distance between t and now, e.g. "3 minutes ago".
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	suffix := "ago"
+	if d < 0 {
+		d = -d
+		suffix = "from now"
+	}
+
+	var amount int64
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		amount, unit = int64(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		amount, unit = int64(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		amount, unit = int64(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		amount, unit = int64(d/(30*24*time.Hour)), "month"
+	default:
+		amount, unit = int64(d/(365*24*time.Hour)), "year"
+	}
+
+	if amount != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s %s", amount, unit, suffix)
+}
+
+// getUserLocation returns the time zone the user prefers in Mattermost, falling back to UTC.
+func (p *Plugin) getUserLocation(userID string) *time.Location {
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		p.API.LogDebug("failed to get user to resolve the timezone", "user_id", userID, "err", appErr.Error())
+		return time.UTC
+	}
+
+	name := user.GetPreferredTimezone()
+	if name == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		p.API.LogDebug("failed to load the user timezone", "timezone", name, "err", err.Error())
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		t        time.Time
+		expected string
+	}{
+		"just now":        {t: now.Add(-30 * time.Second), expected: "just now"},
+		"one minute":      {t: now.Add(-time.Minute), expected: "1 minute ago"},
+		"minutes":         {t: now.Add(-3*time.Minute - 20*time.Second), expected: "3 minutes ago"},
+		"hours":           {t: now.Add(-5 * time.Hour), expected: "5 hours ago"},
+		"one day":         {t: now.Add(-24 * time.Hour), expected: "1 day ago"},
+		"months":          {t: now.Add(-65 * 24 * time.Hour), expected: "2 months ago"},
+		"years":           {t: now.Add(-800 * 24 * time.Hour), expected: "2 years ago"},
+		"in the future":   {t: now.Add(2 * time.Hour), expected: "2 hours from now"},
+		"other location":  {t: now.In(time.FixedZone("UTC+3", 3*60*60)).Add(-10 * time.Minute), expected: "10 minutes ago"},
+		"barely a minute": {t: now.Add(-59 * time.Second), expected: "just now"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := relativeTime(tc.t, now); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTimeLayout(t *testing.T) {
+	for name, tc := range map[string]struct {
+		format   string
+		expected string
+	}{
+		"default": {format: "", expected: time.RFC1123},
+		"RFC3339": {format: "RFC3339", expected: time.RFC3339},
+		"Kitchen": {format: "Kitchen", expected: time.Kitchen},
+		"custom":  {format: "2006-01-02 15:04 MST", expected: "2006-01-02 15:04 MST"},
+		"unknown": {format: "rfc3339", expected: "rfc3339"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := timeLayout(tc.format); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Skipf("the time zone database is not available: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("the time zone database is not available: %v", err)
+	}
+	at := time.Date(2024, 7, 1, 12, 30, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		t        time.Time
+		loc      *time.Location
+		layout   string
+		expected string
+	}{
+		"UTC":              {t: at, loc: time.UTC, layout: time.RFC1123, expected: "Mon, 01 Jul 2024 12:30:00 UTC"},
+		"no location":      {t: at, layout: time.RFC1123, expected: "Mon, 01 Jul 2024 12:30:00 UTC"},
+		"summer time":      {t: at, loc: kyiv, layout: time.RFC1123, expected: "Mon, 01 Jul 2024 15:30:00 EEST"},
+		"winter time":      {t: at.AddDate(0, 6, 0), loc: kyiv, layout: time.RFC1123, expected: "Wed, 01 Jan 2025 14:30:00 EET"},
+		"previous day":     {t: time.Date(2024, 7, 1, 2, 0, 0, 0, time.UTC), loc: newYork, layout: timeLayout("DateTime"), expected: "2024-06-30 22:00:00"},
+		"custom layout":    {t: at, loc: newYork, layout: timeLayout("02.01.2006 15:04 MST"), expected: "01.07.2024 08:30 EDT"},
+		"fixed zone":       {t: at, loc: time.FixedZone("UTC+5:30", 5*60*60+30*60), layout: time.RFC3339, expected: "2024-07-01T18:00:00+05:30"},
+		"zero time is n/a": {t: time.Time{}, loc: kyiv, layout: time.RFC1123, expected: "n/a"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := formatTime(tc.t, tc.loc, tc.layout); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormatTimeAgo(t *testing.T) {
+	at := time.Date(2024, 7, 1, 12, 30, 0, 0, time.UTC)
+	loc := time.FixedZone("UTC+3", 3*60*60)
+
+	if actual, expected := formatTimeAgo(at, loc, time.DateTime, at.Add(3*time.Minute)), "2024-07-01 15:30:00 (3 minutes ago)"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual := formatTimeAgo(time.Time{}, loc, time.DateTime, at); actual != "n/a" {
+		t.Errorf("expected n/a, got %q", actual)
+	}
+}
@@ -0,0 +1,96 @@
+// Package uptime is the provider-neutral model of the alerts of the uptime monitoring services.
+// The providers (Pingdom, UptimeRobot, StatusCake) decode their webhook payloads into Alert, the
+// rest of the plugin (routing, rendering, threading, history) works with Alert only.
+package uptime
+
+import (
+	"time"
+)
+
+// The providers the hook can receive the alerts from.
+const (
+	ProviderPingdom     = "pingdom"
+	ProviderUptimeRobot = "uptimerobot"
+	ProviderStatusCake  = "statuscake"
+)
+
+// The states the providers are normalized to, the Pingdom transaction checks report FAILING and
+// SUCCESS as well.
+const (
+	StateUp      = "UP"
+	StateDown    = "DOWN"
+	StateFailing = "FAILING"
+)
+
+// Probe is the location the check had been run from.
+type Probe struct {
+	IP       string `json:"ip,omitempty"`
+	IPV6     string `json:"ipv6,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// Alert is the state change of the check. The JSON names follow the Pingdom webhook, as the alerts
+// are kept in the post props (the webapp renders them) and in the KV store.
+type Alert struct {
+	Provider string `json:"provider,omitempty"`
+
+	CheckID     uint64                 `json:"check_id"`
+	CheckName   string                 `json:"check_name"`
+	CheckType   string                 `json:"check_type"`
+	CheckParams map[string]interface{} `json:"check_params,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+
+	PreviousState   string `json:"previous_state"`
+	CurrentState    string `json:"current_state"`
+	ImportanceLevel string `json:"importance_level,omitempty"`
+	// StateChangedTimestamp is the Unix time (in seconds) the state had been changed at.
+	StateChangedTimestamp int64  `json:"state_changed_timestamp"`
+	Description           string `json:"short_description"`
+	LongDescription       string `json:"long_description"`
+
+	FirstProbe  *Probe `json:"first_probe,omitempty"`
+	SecondProbe *Probe `json:"second_probe,omitempty"`
+
+	// IncidentID identifies the state change when the provider sends no timestamp of it.
+	IncidentID uint64 `json:"incident_id,omitempty"`
+	// MonitoredURL is the URL the check is monitoring, if any.
+	MonitoredURL string `json:"monitored_url,omitempty"`
+	// ReportURL is the page of the check at the provider, if the provider has a stable one.
+	ReportURL string `json:"report_url,omitempty"`
+
+	// Reconciled is set when the webhook of the state change had been missed and the state change
+	// is found out from the provider API afterwards.
+	Reconciled bool `json:"reconciled,omitempty"`
+	// Test is set on the test alerts, they are posted, but the state of the check, its history and
+	// the stats of the hook are not touched.
+	Test bool `json:"test,omitempty"`
+}
+
+// GetProvider returns the provider of the alert, the alerts stored before the providers had been
+// introduced are Pingdom ones.
+func (a *Alert) GetProvider() string {
+	if a.Provider == "" {
+		return ProviderPingdom
+	}
+	return a.Provider
+}
+
+// ChangedAt returns the time the state had been changed at (zero if unknown).
+func (a *Alert) ChangedAt() time.Time {
+	if a.StateChangedTimestamp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(a.StateChangedTimestamp, 0)
+}
+
+// ProviderName returns the human-readable name of the provider.
+func ProviderName(provider string) string {
+	switch provider {
+	case ProviderUptimeRobot:
+		return "UptimeRobot"
+	case ProviderStatusCake:
+		return "StatusCake"
+	default:
+		return "Pingdom"
+	}
+}
@@ -0,0 +1,93 @@
+package uptime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Decoder decodes the webhook payload of the provider into the alert. receivedAt is used when the
+// payload has no timestamp of the state change.
+type Decoder func(body []byte, contentType string, receivedAt time.Time) (Alert, error)
+
+// IsForm tells whether the payload is form-encoded.
+func IsForm(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/x-www-form-urlencoded"
+}
+
+// StrictJSON returns the single JSON object of the payload. The unknown fields are up to the
+// caller, but the trailing data is rejected.
+func StrictJSON(body []byte) (json.RawMessage, error) {
+	var payload json.RawMessage
+
+	decoder := json.NewDecoder(strings.NewReader(string(body)))
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after the JSON object")
+	}
+	return payload, nil
+}
+
+// Fields are the flat key/value pairs of the payloads of UptimeRobot and StatusCake, the keys are
+// matched case-insensitively.
+type Fields map[string]string
+
+// Get returns the value of the key.
+func (f Fields) Get(key string) string {
+	if v, ok := f[key]; ok {
+		return v
+	}
+	for k, v := range f {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// ParseFields parses the form-encoded payload or the flat JSON object (the values of which are
+// converted to strings).
+func ParseFields(body []byte, contentType string) (Fields, error) {
+	if IsForm(contentType) {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		fields := make(Fields, len(values))
+		for k := range values {
+			fields[k] = values.Get(k)
+		}
+		return fields, nil
+	}
+
+	payload, err := StrictJSON(body)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, err
+	}
+	fields := make(Fields, len(raw))
+	for k, v := range raw {
+		switch value := v.(type) {
+		case nil:
+		case string:
+			fields[k] = value
+		case float64:
+			fields[k] = strconv.FormatFloat(value, 'f', -1, 64)
+		default:
+			fields[k] = fmt.Sprint(value)
+		}
+	}
+	return fields, nil
+}
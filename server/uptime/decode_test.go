@@ -0,0 +1,54 @@
+package uptime
+
+import (
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body        string
+		contentType string
+		expected    map[string]string
+		expectedErr bool
+	}{
+		"form": {
+			body:        "Name=example.com&Status=Up",
+			contentType: "application/x-www-form-urlencoded; charset=utf-8",
+			expected:    map[string]string{"name": "example.com", "STATUS": "Up"},
+		},
+		"JSON": {
+			body:     `{"monitorID": 777, "monitorFriendlyName": "example.com", "ssl": true, "empty": null}`,
+			expected: map[string]string{"monitorid": "777", "monitorFriendlyName": "example.com", "ssl": "true", "empty": ""},
+		},
+		"large number": {
+			body:     `{"monitorID": 12345678901}`,
+			expected: map[string]string{"monitorID": "12345678901"},
+		},
+		"trailing data": {
+			body:        `{"monitorID": 777} {}`,
+			expectedErr: true,
+		},
+		"not an object": {
+			body:        `[777]`,
+			expectedErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fields, err := ParseFields([]byte(tc.body), tc.contentType)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			for key, expected := range tc.expected {
+				if actual := fields.Get(key); actual != expected {
+					t.Errorf("expected %s to be %q, got %q", key, expected, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package uptimerobot adapts the UptimeRobot webhook alerts to the provider-neutral model.
+// Ref.: https://uptimerobot.com/api/ (Alert Contacts, Webhook)
+package uptimerobot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+// Alert types of UptimeRobot.
+const (
+	AlertTypeDown      = "1"
+	AlertTypeUp        = "2"
+	AlertTypeSSLExpiry = "3"
+)
+
+// dashboardURL is the page of the monitor in the UptimeRobot dashboard.
+const dashboardURL = "https://dashboard.uptimerobot.com/monitors/%d"
+
+// DecodeAlert is the uptime.Decoder of the UptimeRobot webhooks. The webhook is posted either
+// form-encoded or as the JSON object built from the UptimeRobot variables, e.g.
+// {"monitorID": "*monitorID*", "monitorFriendlyName": "*monitorFriendlyName*", ...}.
+func DecodeAlert(body []byte, contentType string, receivedAt time.Time) (uptime.Alert, error) {
+	fields, err := uptime.ParseFields(body, contentType)
+	if err != nil {
+		return uptime.Alert{}, err
+	}
+
+	monitorID := fields.Get("monitorID")
+	if monitorID == "" {
+		return uptime.Alert{}, errors.New("missing monitorID")
+	}
+	checkID, err := strconv.ParseUint(monitorID, 10, 64)
+	if err != nil {
+		return uptime.Alert{}, fmt.Errorf("invalid monitorID %q", monitorID)
+	}
+
+	a := uptime.Alert{
+		Provider:              uptime.ProviderUptimeRobot,
+		CheckID:               checkID,
+		CheckName:             fields.Get("monitorFriendlyName"),
+		Description:           fields.Get("alertTypeFriendlyName"),
+		LongDescription:       fields.Get("alertDetails"),
+		MonitoredURL:          fields.Get("monitorURL"),
+		ReportURL:             fmt.Sprintf(dashboardURL, checkID),
+		StateChangedTimestamp: receivedAt.Unix(),
+	}
+
+	switch fields.Get("alertType") {
+	case AlertTypeDown:
+		a.CurrentState, a.PreviousState = uptime.StateDown, uptime.StateUp
+	case AlertTypeUp:
+		a.CurrentState, a.PreviousState = uptime.StateUp, uptime.StateDown
+	case AlertTypeSSLExpiry:
+		a.CurrentState = "SSL_EXPIRY"
+	default:
+		a.CurrentState = strings.ToUpper(a.Description)
+	}
+
+	if alertDateTime := fields.Get("alertDateTime"); alertDateTime != "" {
+		if ts, err := strconv.ParseInt(alertDateTime, 10, 64); err == nil && ts > 0 {
+			a.StateChangedTimestamp = ts
+		}
+	}
+
+	params := make(map[string]interface{})
+	for key, name := range map[string]string{
+		"monitorURL":        "url",
+		"alertDuration":     "alert_duration_seconds",
+		"sslExpiryDate":     "ssl_expiry_date",
+		"sslExpiryDaysLeft": "ssl_expiry_days_left",
+	} {
+		if value := fields.Get(key); value != "" {
+			params[name] = value
+		}
+	}
+	if len(params) > 0 {
+		a.CheckParams = params
+	}
+	return a, nil
+}
@@ -0,0 +1,119 @@
+package uptimerobot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+const formContentType = "application/x-www-form-urlencoded"
+
+func TestDecodeAlert(t *testing.T) {
+	receivedAt := time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		payload          string
+		contentType      string
+		expectedState    string
+		expectedPrevious string
+		expectedChanged  int64
+	}{
+		"down as JSON": {
+			payload:          `{"monitorID": "777", "monitorFriendlyName": "example.com", "alertType": "1", "alertTypeFriendlyName": "Down", "alertDateTime": "1709288400"}`,
+			contentType:      "application/json",
+			expectedState:    uptime.StateDown,
+			expectedPrevious: uptime.StateUp,
+			expectedChanged:  1709288400,
+		},
+		"up as form": {
+			payload:          "monitorID=777&monitorFriendlyName=example.com&alertType=2&alertTypeFriendlyName=Up",
+			contentType:      formContentType,
+			expectedState:    uptime.StateUp,
+			expectedPrevious: uptime.StateDown,
+			expectedChanged:  receivedAt.Unix(),
+		},
+		"SSL expiry": {
+			payload:         "monitorID=777&monitorFriendlyName=example.com&alertType=3&sslExpiryDaysLeft=7",
+			contentType:     formContentType,
+			expectedState:   "SSL_EXPIRY",
+			expectedChanged: receivedAt.Unix(),
+		},
+		"unknown alert type": {
+			payload:         "monitorID=777&monitorFriendlyName=example.com&alertType=9&alertTypeFriendlyName=Paused",
+			contentType:     formContentType,
+			expectedState:   "PAUSED",
+			expectedChanged: receivedAt.Unix(),
+		},
+		"invalid alert time": {
+			payload:          "monitorID=777&monitorFriendlyName=example.com&alertType=1&alertDateTime=yesterday",
+			contentType:      formContentType,
+			expectedState:    uptime.StateDown,
+			expectedPrevious: uptime.StateUp,
+			expectedChanged:  receivedAt.Unix(),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			alert, err := DecodeAlert([]byte(tc.payload), tc.contentType, receivedAt)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if alert.Provider != uptime.ProviderUptimeRobot || alert.CheckID != 777 || alert.CheckName != "example.com" {
+				t.Errorf("unexpected check: %+v", alert)
+			}
+			if alert.CurrentState != tc.expectedState || alert.PreviousState != tc.expectedPrevious {
+				t.Errorf("expected %q -> %q, got %q -> %q", tc.expectedPrevious, tc.expectedState, alert.PreviousState, alert.CurrentState)
+			}
+			if alert.StateChangedTimestamp != tc.expectedChanged {
+				t.Errorf("expected the state change time %d, got %d", tc.expectedChanged, alert.StateChangedTimestamp)
+			}
+			if alert.ReportURL != "https://dashboard.uptimerobot.com/monitors/777" {
+				t.Errorf("unexpected report URL %s", alert.ReportURL)
+			}
+		})
+	}
+}
+
+func TestDecodeAlertParams(t *testing.T) {
+	payload := "monitorID=777&monitorURL=https%3A%2F%2Fexample.com&alertType=1&alertDuration=120"
+	alert, err := DecodeAlert([]byte(payload), formContentType, time.Now())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if alert.MonitoredURL != "https://example.com" {
+		t.Errorf("expected the monitored URL, got %q", alert.MonitoredURL)
+	}
+	for key, expected := range map[string]string{
+		"url":                    "https://example.com",
+		"alert_duration_seconds": "120",
+	} {
+		if alert.CheckParams[key] != expected {
+			t.Errorf("expected the check param %s to be %q, got %v", key, expected, alert.CheckParams[key])
+		}
+	}
+	if len(alert.CheckParams) != 2 {
+		t.Errorf("expected the check params of the sent fields only, got %v", alert.CheckParams)
+	}
+}
+
+func TestDecodeAlertMalformed(t *testing.T) {
+	for name, tc := range map[string]struct {
+		payload     string
+		contentType string
+	}{
+		"missing monitor ID":  {payload: `{"monitorFriendlyName": "example.com", "alertType": "1"}`, contentType: "application/json"},
+		"invalid monitor ID":  {payload: "monitorID=abc&alertType=1", contentType: formContentType},
+		"negative monitor ID": {payload: "monitorID=-1&alertType=1", contentType: formContentType},
+		"invalid JSON":        {payload: `{"monitorID": "777"`, contentType: "application/json"},
+		"not an object":       {payload: `["777"]`, contentType: "application/json"},
+		"trailing data":       {payload: `{"monitorID": "777"} {}`, contentType: "application/json"},
+		"invalid form":        {payload: "monitorID=%zz", contentType: formContentType},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecodeAlert([]byte(tc.payload), tc.contentType, time.Now()); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+// silenceWatchdogInterval is how often the hooks are checked for the silence.
+const silenceWatchdogInterval = 15 * time.Minute
+
+// watchdogState is kept in the KV store per hook. The timestamps are in milliseconds.
+type watchdogState struct {
+	// WatchedSince is when the watchdog saw the hook first, the hooks which had never received an
+	// alert are silent since then.
+	WatchedSince int64 `json:"watched_since"`
+	// ConfirmedAt is when the Pingdom API confirmed the silence is expected: no check had failed
+	// since the last alert.
+	ConfirmedAt int64 `json:"confirmed_at,omitempty"`
+	// WarnedAt is when the silence had been reported, it is reported once per silence.
+	WarnedAt int64 `json:"warned_at,omitempty"`
+}
+
+func watchdogStateKey(hookID string) string {
+	return fmt.Sprintf("watchdog_%s", hookID)
+}
+
+// GetHeartbeatInterval returns how long the hook may stay silent, 0 if the watchdog is off.
+func (ac *pingdomHookConfig) GetHeartbeatInterval() time.Duration {
+	return time.Duration(ac.HeartbeatIntervalHours) * time.Hour
+}
+
+// lastHeartbeat returns when the hook was known to work last time: the last alert or the last
+// confirmation of the Pingdom API.
+func (s watchdogState) lastHeartbeat(lastReceivedAt int64) int64 {
+	return max(s.WatchedSince, s.ConfirmedAt, lastReceivedAt)
+}
+
+// isSilent tells if the hook had not been heard of for the interval.
+func (s watchdogState) isSilent(lastReceivedAt int64, now time.Time, interval time.Duration) bool {
+	return now.Sub(time.UnixMilli(s.lastHeartbeat(lastReceivedAt))) >= interval
+}
+
+// isWarned tells if the current silence had been reported already.
+func (s watchdogState) isWarned(lastReceivedAt int64) bool {
+	return s.WarnedAt > s.lastHeartbeat(lastReceivedAt)
+}
+
+// missedChecks returns the checks of the hook which failed after its last alert, i.e. Pingdom had
+// something to report, but the hook had not been called. The checks the hook had never received the
+// alerts of are not its checks: the account may have the checks alerting to other integrations.
+func missedChecks(checks []pingdom.Check, recorded map[uint64]*checkState, lastReceivedAt int64) []pingdom.Check {
+	var missed []pingdom.Check
+	for _, check := range checks {
+		if recorded[check.ID] == nil || check.Status == pingdom.CheckStatusPaused {
+			continue
+		}
+		if check.Status == pingdom.CheckStatusDown || check.LastErrorTime*1000 > lastReceivedAt {
+			missed = append(missed, check)
+		}
+	}
+	return missed
+}
+
+// checkSilentHooks is the cluster job which reports the hooks which stopped receiving the alerts.
+func (p *Plugin) checkSilentHooks() {
+	p.flushHookStats()
+	for _, config := range p.getConfiguration().PingdomHooksConfigs {
+		if config.Disabled || config.GetHeartbeatInterval() == 0 {
+			continue
+		}
+		if err := p.checkHookSilence(config, time.Now()); err != nil {
+			p.API.LogWarn("Failed to check the hook for the silence", "hook_id", config.ID, "err", err.Error())
+		}
+	}
+}
+
+func (p *Plugin) checkHookSilence(config pingdomHookConfig, now time.Time) error {
+	var state watchdogState
+	if err := p.client.KV.Get(watchdogStateKey(config.ID), &state); err != nil {
+		return fmt.Errorf("failed to get the watchdog state: %w", err)
+	}
+	if state.WatchedSince == 0 {
+		state.WatchedSince = now.UnixMilli()
+		return p.saveWatchdogState(config.ID, state)
+	}
+
+	stats, err := p.getHookStats(config.ID)
+	if err != nil {
+		return err
+	}
+	interval := config.GetHeartbeatInterval()
+	if !state.isSilent(stats.LastReceivedAt, now, interval) || state.isWarned(stats.LastReceivedAt) {
+		return nil
+	}
+
+	// The checks may just be healthy: the Pingdom API tells if there was anything to report. The
+	// hook which had never received an alert has no checks to ask about.
+	var missed []pingdom.Check
+	if config.Token != "" && config.GetProvider() == uptime.ProviderPingdom {
+		recorded, err := p.listCheckStates(config.ID)
+		if err != nil {
+			return err
+		}
+		if len(recorded) > 0 {
+			missed, err = p.confirmSilence(config, recorded, stats.LastReceivedAt)
+			if err != nil {
+				p.API.LogWarn("Failed to confirm the silence with the Pingdom API", "hook_id", config.ID, "err", err.Error())
+			} else if len(missed) == 0 {
+				state.ConfirmedAt = now.UnixMilli()
+				return p.saveWatchdogState(config.ID, state)
+			}
+		}
+	}
+
+	p.warnSilentHook(config, state.lastHeartbeat(stats.LastReceivedAt), missed)
+	state.WarnedAt = now.UnixMilli()
+	return p.saveWatchdogState(config.ID, state)
+}
+
+// confirmSilence returns the checks of the hook the Pingdom API reports the failures of since the
+// last alert.
+func (p *Plugin) confirmSilence(config pingdomHookConfig, recorded map[uint64]*checkState, lastReceivedAt int64) ([]pingdom.Check, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	checks, err := p.newPingdomClient(config.Token).GetChecks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return missedChecks(checks, recorded, lastReceivedAt), nil
+}
+
+func (p *Plugin) saveWatchdogState(hookID string, state watchdogState) error {
+	if _, err := p.client.KV.Set(watchdogStateKey(hookID), state); err != nil {
+		return fmt.Errorf("failed to save the watchdog state: %w", err)
+	}
+	return nil
+}
+
+// warnSilentHook posts the silence warning to the channel of the hook and to the system admins.
+func (p *Plugin) warnSilentHook(config pingdomHookConfig, lastHeartbeat int64, missed []pingdom.Check) {
+	loc, err := config.GetLocation()
+	if err != nil {
+		loc = time.UTC
+	}
+
+	message := fmt.Sprintf(":warning: The %s webhook `%s` (%s) had not been called since %s. "+
+		"Check that the integration is enabled and the seed is up to date, e.g. with `/pingdom test`.",
+		uptime.ProviderName(config.GetProvider()), escapeCode(config.ID), config.GetTargetMention(),
+		formatTime(time.UnixMilli(lastHeartbeat), loc, config.GetTimeLayout()))
+	if len(missed) > 0 {
+		names := make([]string, 0, len(missed))
+		for _, check := range missed {
+			names = append(names, fmt.Sprintf("%s (%s)", escapeMarkdown(check.Name), check.Status))
+		}
+		message += fmt.Sprintf(" The Pingdom API reports the failures which had not been received: %s.", strings.Join(names, ", "))
+	}
+
+	if channelID, err := p.getHookChannelID(config); err == nil {
+		if _, appErr := p.API.CreatePost(&model.Post{ChannelId: channelID, UserId: p.BotUserID, Message: message}); appErr != nil {
+			p.API.LogWarn("failed to post the silence warning", "hook_id", config.ID, "err", appErr.Error())
+		}
+	}
+	p.notifySystemAdmins(message)
+}
+
+// notifySystemAdmins sends the message to every system admin as the direct message of the bot.
+func (p *Plugin) notifySystemAdmins(message string) {
+	const perPage = 100
+	for page := 0; ; page++ {
+		admins, appErr := p.API.GetUsers(&model.UserGetOptions{Role: model.SystemAdminRoleId, Active: true, Page: page, PerPage: perPage})
+		if appErr != nil {
+			p.API.LogWarn("failed to list the system admins", "err", appErr.Error())
+			return
+		}
+		for _, admin := range admins {
+			channel, appErr := p.API.GetDirectChannel(admin.Id, p.BotUserID)
+			if appErr != nil {
+				p.API.LogWarn("failed to get the direct channel", "user_id", admin.Id, "err", appErr.Error())
+				continue
+			}
+			if _, appErr = p.API.CreatePost(&model.Post{ChannelId: channel.Id, UserId: p.BotUserID, Message: message}); appErr != nil {
+				p.API.LogWarn("failed to notify the system admin", "user_id", admin.Id, "err", appErr.Error())
+			}
+		}
+		if len(admins) < perPage {
+			return
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+)
+
+func TestWatchdogState(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	hoursAgo := func(hours int) int64 {
+		return now.Add(-time.Duration(hours) * time.Hour).UnixMilli()
+	}
+
+	for name, tc := range map[string]struct {
+		state          watchdogState
+		lastReceivedAt int64
+		silent         bool
+		warned         bool
+	}{
+		"recent alert": {
+			state:          watchdogState{WatchedSince: hoursAgo(100)},
+			lastReceivedAt: hoursAgo(2),
+		},
+		"never received, watched recently": {
+			state: watchdogState{WatchedSince: hoursAgo(5)},
+		},
+		"never received": {
+			state:  watchdogState{WatchedSince: hoursAgo(30)},
+			silent: true,
+		},
+		"old alert": {
+			state:          watchdogState{WatchedSince: hoursAgo(100)},
+			lastReceivedAt: hoursAgo(25),
+			silent:         true,
+		},
+		"silence confirmed by the API": {
+			state:          watchdogState{WatchedSince: hoursAgo(100), ConfirmedAt: hoursAgo(1)},
+			lastReceivedAt: hoursAgo(50),
+		},
+		"silence reported": {
+			state:          watchdogState{WatchedSince: hoursAgo(100), WarnedAt: hoursAgo(1)},
+			lastReceivedAt: hoursAgo(50),
+			silent:         true,
+			warned:         true,
+		},
+		"alert after the report": {
+			state:          watchdogState{WatchedSince: hoursAgo(100), WarnedAt: hoursAgo(40)},
+			lastReceivedAt: hoursAgo(30),
+			silent:         true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if silent := tc.state.isSilent(tc.lastReceivedAt, now, 24*time.Hour); silent != tc.silent {
+				t.Errorf("expected silent %v, got %v", tc.silent, silent)
+			}
+			if warned := tc.state.isWarned(tc.lastReceivedAt); warned != tc.warned {
+				t.Errorf("expected warned %v, got %v", tc.warned, warned)
+			}
+		})
+	}
+}
+
+func TestMissedChecks(t *testing.T) {
+	lastReceivedAt := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+	before, after := lastReceivedAt/1000-60, lastReceivedAt/1000+60
+
+	checks := []pingdom.Check{
+		{ID: 1, Name: "healthy", Status: pingdom.CheckStatusUp, LastErrorTime: before},
+		{ID: 2, Name: "down", Status: pingdom.CheckStatusDown, LastErrorTime: before},
+		{ID: 3, Name: "recovered", Status: pingdom.CheckStatusUp, LastErrorTime: after},
+		{ID: 4, Name: "paused", Status: pingdom.CheckStatusPaused, LastErrorTime: after},
+		{ID: 5, Name: "never failed", Status: pingdom.CheckStatusUp},
+		{ID: 6, Name: "other integration", Status: pingdom.CheckStatusDown, LastErrorTime: after},
+	}
+	recorded := map[uint64]*checkState{1: {}, 2: {}, 3: {}, 4: {}, 5: {}}
+
+	missed := missedChecks(checks, recorded, lastReceivedAt)
+	if len(missed) != 2 || missed[0].ID != 2 || missed[1].ID != 3 {
+		t.Errorf("expected the checks 2 and 3 to be missed, got %+v", missed)
+	}
+}
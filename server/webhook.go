@@ -2,52 +2,234 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/statuscake"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptimerobot"
+)
+
+const (
+	// alertPostType is the custom post type the webapp renders the live-updating alert card for.
+	// The other clients (e.g. mobile) fall back to the attachment of the post.
+	alertPostType = "custom_pingdom_alert"
+
+	propAlert          = "pingdom_alert"
+	propHookID         = "pingdom_hook_id"
+	propState          = "pingdom_state"
+	propTitleLink      = "pingdom_title_link"
+	propAcknowledgedBy = "pingdom_acknowledged_by"
+	propAcknowledgedAt = "pingdom_acknowledged_at"
+	// The state change time formatted in the Timezone and the TimeFormat of the hook, the card does not
+	// format it in the zone of the browser.
+	propChangedAt  = "pingdom_changed_at"
+	propTimezone   = "pingdom_timezone"
+	propTimeFormat = "pingdom_time_format"
 )
 
 func (p *Plugin) handleWebhook(w http.ResponseWriter, r *http.Request, pingdomHookConfig pingdomHookConfig) {
-	p.API.LogInfo("Received pingdom notification")
+	p.API.LogInfo("Received pingdom notification", "hook_id", pingdomHookConfig.ID, "provider", pingdomHookConfig.GetProvider())
+	m := p.getMetrics()
+	defer func(start time.Time) {
+		m.processing.WithLabelValues(pingdomHookConfig.ID).Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		p.API.LogError("failed to read webhook message", "err", err.Error())
+		p.rejectWebhook(w, pingdomHookConfig.ID, rejectInvalidPayload, "Failed to read message", http.StatusBadRequest)
+		return
+	}
 
-	var message pingdom.PingdomCheckMessage
-	err := json.NewDecoder(r.Body).Decode(&message)
+	message, err := decodeAlert(pingdomHookConfig.GetProvider(), body, r.Header.Get("Content-Type"), time.Now())
 	if err != nil {
 		p.API.LogError("failed to decode webhook message", "err", err.Error())
-		http.Error(w, "Failed to decode message", http.StatusBadRequest)
+		p.rejectWebhook(w, pingdomHookConfig.ID, rejectInvalidPayload, "Failed to decode message", http.StatusBadRequest)
 		return
 	}
 
+	message.Test = isTestAlertRequest(r)
+
 	if message.CheckID == 0 || message.CheckName == "" {
 		p.API.LogError("invalid webhook message", "err", "missing check id or name")
+		m.webhooksRejected.WithLabelValues(pingdomHookConfig.ID, rejectInvalidPayload).Inc()
+		p.recordAlertRejected(pingdomHookConfig.ID, "Missing check ID or name")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
+	// The channel which failed to be resolved before is resolved again, the alert is queued anyway.
+	if _, err := p.getHookChannelID(pingdomHookConfig); err != nil {
+		p.API.LogWarn("The alert is queued until the channel is resolved", "hook_id", pingdomHookConfig.ID, "err", err.Error())
+	}
+
+	accepted, err := p.acceptAlert(pingdomHookConfig, message)
+	if err != nil {
+		p.API.LogError("failed to queue the pingdom notification", "hook_id", pingdomHookConfig.ID, "err", err.Error())
+		p.rejectWebhook(w, pingdomHookConfig.ID, rejectQueueFailed, "Failed to queue the alert", http.StatusServiceUnavailable)
+		return
+	}
+	if !accepted {
+		p.API.LogInfo("Skipping the duplicate pingdom notification", "hook_id", pingdomHookConfig.ID, "check_id", message.CheckID, "state", message.CurrentState)
+		m.webhooksRejected.WithLabelValues(pingdomHookConfig.ID, rejectDuplicate).Inc()
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	m.webhooksReceived.WithLabelValues(pingdomHookConfig.ID).Inc()
+	if !message.Test {
+		p.recordAlertReceived(pingdomHookConfig.ID, message.CheckName)
+	}
+	w.WriteHeader(http.StatusAccepted)
+	p.API.LogDebug("Pingdom notification is queued.")
+}
+
+// acceptAlert records the alert in the history and queues it for the delivery. It returns false if
+// the state change had been accepted already.
+func (p *Plugin) acceptAlert(pingdomHookConfig pingdomHookConfig, message uptime.Alert) (bool, error) {
+	// Pingdom retries the webhook and sometimes delivers the same state change twice: the repeats
+	// are acknowledged, but not posted again.
+	claimed, err := p.claimAlert(pingdomHookConfig.ID, message)
+	if err != nil {
+		p.API.LogWarn("failed to check the alert for the duplicate", "hook_id", pingdomHookConfig.ID, "check_id", message.CheckID, "err", err.Error())
+	} else if !claimed {
+		return false, nil
+	}
+
+	if !message.Test {
+		if err = p.recordHistory(pingdomHookConfig, message); err != nil {
+			p.API.LogWarn("failed to record the alert history", "hook_id", pingdomHookConfig.ID, "check_id", message.CheckID, "err", err.Error())
+		}
+	}
+
+	// The alert is accepted into the persistent queue and posted by the delivery worker, so it is
+	// not lost when the channel or the database is briefly unavailable.
+	if err = p.enqueueAlert(pingdomHookConfig.ID, message); err != nil {
+		if claimed {
+			if releaseErr := p.releaseAlert(pingdomHookConfig.ID, message); releaseErr != nil {
+				p.API.LogWarn("failed to release the undelivered alert", "hook_id", pingdomHookConfig.ID, "err", releaseErr.Error())
+			}
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// deliverAlert posts the alert to the channel of the hook and records the new state of the check, it
+// returns the ID of the post.
+func (p *Plugin) deliverAlert(pingdomHookConfig pingdomHookConfig, message uptime.Alert) (string, error) {
+	channelID, err := p.getHookChannelID(pingdomHookConfig)
+	if err != nil {
+		p.recordDeliveryFailure(pingdomHookConfig.ID, err)
+		return "", err
+	}
+
 	var fields []*model.SlackAttachmentField
-	fields = append(fields, ConvertPingdomToFields(pingdomHookConfig, message)...)
+	fields = append(fields, ConvertAlertToFields(pingdomHookConfig, message)...)
 
+	text := fmt.Sprintf("%s alert had been received.", uptime.ProviderName(message.GetProvider()))
+	if message.Reconciled {
+		text = fmt.Sprintf("%s alert had been missed, the state change is reconciled from the %s API.",
+			uptime.ProviderName(message.GetProvider()), uptime.ProviderName(message.GetProvider()))
+	}
 	attachment := &model.SlackAttachment{
-		Text:   "Pingdom alert had been received.",
-		Title:  fmt.Sprintf("%s: %s", message.CheckType, message.CheckName),
-		Fields: fields,
-		Color:  setColor(message.CurrentState),
+		Text:      text,
+		Title:     alertTitle(message),
+		TitleLink: alertReportURL(pingdomHookConfig, message),
+		Fields:    fields,
+		Color:     setColor(message.CurrentState),
 	}
 
 	post := &model.Post{
-		ChannelId: p.PingdomHooksConfigIDChannelID[pingdomHookConfig.ID],
+		ChannelId: channelID,
 		UserId:    p.BotUserID,
 	}
 
+	sanitizeAttachment(attachment)
 	model.ParseSlackAttachment(post, []*model.SlackAttachment{attachment})
-	if _, appErr := p.API.CreatePost(post); appErr != nil {
-		return
+	post.Type = alertPostType
+	if alertProp, err := toPropValue(message); err == nil {
+		post.AddProp(propAlert, alertProp)
+	} else {
+		p.API.LogWarn("failed to convert the alert into the post props", "err", err.Error())
+	}
+	post.AddProp(propHookID, pingdomHookConfig.ID)
+	post.AddProp(propState, message.CurrentState)
+	post.AddProp(propTitleLink, attachment.TitleLink)
+	loc, err := pingdomHookConfig.GetLocation()
+	if err != nil {
+		loc = time.UTC
+	}
+	post.AddProp(propChangedAt, formatTime(message.ChangedAt(), loc, pingdomHookConfig.GetTimeLayout()))
+	post.AddProp(propTimezone, loc.String())
+	post.AddProp(propTimeFormat, pingdomHookConfig.GetTimeLayout())
+
+	createdPost, appErr := p.API.CreatePost(post)
+	if appErr != nil {
+		err := fmt.Errorf("failed to create the post: %w", appErr)
+		// The channel may have been archived or deleted meanwhile, it is resolved again on the retry.
+		p.channels.invalidate(pingdomHookConfig.ID)
+		p.getMetrics().postFailures.WithLabelValues(pingdomHookConfig.ID).Inc()
+		p.recordDeliveryFailure(pingdomHookConfig.ID, err)
+		return "", err
+	}
+
+	p.recordAlertDelivered(pingdomHookConfig.ID)
+
+	if message.Test {
+		return createdPost.Id, nil
+	}
+	if err := p.saveCheckState(pingdomHookConfig.ID, message, createdPost.Id); err != nil {
+		p.API.LogWarn("failed to record the check state", "check_id", message.CheckID, "err", err.Error())
 	}
 	p.API.LogDebug("Pingdom notification processing is done.")
+	return createdPost.Id, nil
+}
+
+// alertDecoders are the adapters of the providers the hooks can receive the alerts from.
+var alertDecoders = map[string]uptime.Decoder{
+	uptime.ProviderPingdom:     pingdom.DecodeAlert,
+	uptime.ProviderUptimeRobot: uptimerobot.DecodeAlert,
+	uptime.ProviderStatusCake:  statuscake.DecodeAlert,
+}
+
+// decodeAlert decodes the webhook payload with the adapter of the provider.
+func decodeAlert(provider string, body []byte, contentType string, receivedAt time.Time) (uptime.Alert, error) {
+	decoder, ok := alertDecoders[provider]
+	if !ok {
+		return uptime.Alert{}, fmt.Errorf("unknown provider %q", provider)
+	}
+	return decoder(body, contentType, receivedAt)
+}
+
+// alertTitle renders the title of the alert, the legacy Pingdom alerts and some providers have no check type.
+func alertTitle(message uptime.Alert) string {
+	if message.CheckType == "" {
+		return escapeMarkdown(message.CheckName)
+	}
+	return fmt.Sprintf("%s: %s", escapeMarkdown(message.CheckType), escapeMarkdown(message.CheckName))
+}
+
+// toPropValue converts the struct into the generic map, as only the generic types can travel to
+// the server inside the post props.
+func toPropValue(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 func addFields(fields []*model.SlackAttachmentField, title, msg string, short bool) []*model.SlackAttachmentField {
@@ -73,66 +255,132 @@ func setColor(impact string) string {
 	return colorExpired
 }
 
-func ConvertPingdomToFields(config pingdomHookConfig, alert pingdom.PingdomCheckMessage) []*model.SlackAttachmentField {
+// renderMailParams renders the check parameters of the IMAP, POP3 and SMTP checks.
+func renderMailParams(msg string, params pingdom.MailParams) string {
+	msg = fmt.Sprintf("%s**Hostname**: %s\n", msg, naString(params.Hostname))
+	msg = fmt.Sprintf("%s**Port**: %s\n", msg, naNumber(params.Port))
+	msg = fmt.Sprintf("%s**IPv6**: %s\n", msg, naBool(params.IPV6))
+	msg = fmt.Sprintf("%s**Encryption**: %s\n", msg, naBool(params.Encryption))
+	msg = fmt.Sprintf("%s**String To Expect**: %s\n", msg, naCode(params.StringToExpect))
+	return msg
+}
+
+// alertReportURL returns the page of the check at the provider: the uptime report for Pingdom.
+func alertReportURL(config pingdomHookConfig, alert uptime.Alert) string {
+	if alert.GetProvider() == uptime.ProviderPingdom {
+		return pingdom.UptimeReportURL(config.PingdomBaseURL, alert.CheckID)
+	}
+	return alert.ReportURL
+}
+
+// ConvertAlertToFields renders the alert of any provider into the attachment fields.
+func ConvertAlertToFields(config pingdomHookConfig, alert uptime.Alert) []*model.SlackAttachmentField {
 	var fields []*model.SlackAttachmentField
 
-	statusMsg := strings.ToUpper(alert.CurrentState)
+	loc, err := config.GetLocation()
+	if err != nil {
+		loc = time.UTC
+	}
+
+	state := escapeMarkdown(strings.ToUpper(alert.CurrentState))
+	statusMsg := state
 	if alert.CurrentState == "DOWN" || alert.CurrentState == "FAILING" {
-		statusMsg = fmt.Sprintf(":fire: :boom: %s :boom: :fire:", strings.ToUpper(alert.CurrentState))
+		statusMsg = fmt.Sprintf(":fire: :boom: %s :boom: :fire:", state)
 	} else if alert.CurrentState == "UP" || alert.CurrentState == "SUCCESS" {
-		statusMsg = fmt.Sprintf(":white_check_mark: :four_leaf_clover: %s :four_leaf_clover: :white_check_mark:", strings.ToUpper(alert.CurrentState))
+		statusMsg = fmt.Sprintf(":white_check_mark: :four_leaf_clover: %s :four_leaf_clover: :white_check_mark:", state)
 	} else {
-		statusMsg = fmt.Sprintf(":thinking_face: %s :thinking_face:", strings.ToUpper(alert.CurrentState))
+		statusMsg = fmt.Sprintf(":thinking_face: %s :thinking_face:", state)
 	}
 
 	/* The variable which handles messages :) */
 	var msg string
 
 	/* first field: Description, LongDescription and time */
-	msg = fmt.Sprintf("**Description**: %s\n", alert.Description)
-	msg = fmt.Sprintf("%s**Long Description**: %s\n", msg, alert.LongDescription)
+	msg = fmt.Sprintf("**Description**: %s\n", escapeMarkdown(alert.Description))
+	msg = fmt.Sprintf("%s**Long Description**: %s\n", msg, escapeMarkdown(alert.LongDescription))
 	if alert.ImportanceLevel == "HIGH" {
-		msg = fmt.Sprintf("%s**Importance**: :arrow_upper_right: %s :arrow_upper_right:\n", msg, alert.ImportanceLevel)
+		msg = fmt.Sprintf("%s**Importance**: :arrow_upper_right: %s :arrow_upper_right:\n", msg, escapeMarkdown(alert.ImportanceLevel))
 	} else {
-		msg = fmt.Sprintf("%s**Importance**: :arrow_lower_right: %s :arrow_lower_right:\n", msg, alert.ImportanceLevel)
+		msg = fmt.Sprintf("%s**Importance**: :arrow_lower_right: %s :arrow_lower_right:\n", msg, escapeMarkdown(alert.ImportanceLevel))
 	}
-	msg = fmt.Sprintf("%s**Check Type**: %s\n", msg, alert.CheckType)
+	msg = fmt.Sprintf("%s**Check Type**: %s\n", msg, escapeMarkdown(alert.CheckType))
 	msg = fmt.Sprintf("%s \n", msg)
-	msg = fmt.Sprintf("%s**State changed time:** %s\n", msg, alert.StateChangedTimestamp.Format(time.RFC1123))
-	msg = fmt.Sprintf("%s**Previous state:** %s\n", msg, alert.PreviousState)
+	msg = fmt.Sprintf("%s**State changed time:** %s\n", msg, formatTime(alert.ChangedAt(), loc, config.GetTimeLayout()))
+	msg = fmt.Sprintf("%s**Previous state:** %s\n", msg, escapeMarkdown(alert.PreviousState))
 	fields = addFields(fields, statusMsg, msg, true)
 
 	/* second field: Check Parameters */
 	msg = ""
-	if alert.CheckType != "TRANSACTION" {
-		msg = fmt.Sprintf("**Hostname**: %s\n", alert.CheckParams["hostname"])
-	}
-
-	switch alert.CheckType {
-	case "HTTP", "HTTP_CUSTOM":
-		msg = fmt.Sprintf("%s**Port**: %v\n", msg, alert.CheckParams["port"])
-		msg = fmt.Sprintf("%s**URL**: `%s`\n", msg, alert.CheckParams["url"])
-		msg = fmt.Sprintf("%s**IPv6**: %v\n", msg, alert.CheckParams["ipv6"])
-		msg = fmt.Sprintf("%s**Encryption**: %v\n", msg, alert.CheckParams["encryption"])
-	case "DNS":
-		msg = fmt.Sprintf("%s**Expected IP**: `%s`\n", msg, alert.CheckParams["expected_ip"])
-		msg = fmt.Sprintf("%s**Nameserver**: `%s`\n", msg, alert.CheckParams["nameserver"])
-		msg = fmt.Sprintf("%s**IPv6**: %v\n", msg, alert.CheckParams["ipv6"])
-	case "PORT_TCP", "UDP":
-		msg = fmt.Sprintf("%s**Port**: %v\n", msg, alert.CheckParams["port"])
-		msg = fmt.Sprintf("%s**IPv6**: %v\n", msg, alert.CheckParams["ipv6"])
-	case "IMAP", "POP3", "SMTP":
-		msg = fmt.Sprintf("%s**Port**: %v\n", msg, alert.CheckParams["port"])
-		msg = fmt.Sprintf("%s**IPv6**: %v\n", msg, alert.CheckParams["ipv6"])
-		msg = fmt.Sprintf("%s**Encryption**: %v\n", msg, alert.CheckParams["encryption"])
-	case "PING":
-		msg = fmt.Sprintf("%s**IPv6**: %v\n", msg, alert.CheckParams["ipv6"])
-	case "TRANSACTION":
-		msg = fmt.Sprintf("%s**Port**: %v\n", msg, alert.CheckParams["port"])
-		msg = fmt.Sprintf("%s**URL**: `%s`\n", msg, alert.CheckParams["url"])
-		msg = fmt.Sprintf("%s**Encryption**: %v\n", msg, alert.CheckParams["encryption"])
+	// Only the Pingdom checks have the typed parameters, the other providers are rendered generically.
+	var typedParams interface{}
+	if alert.GetProvider() == uptime.ProviderPingdom {
+		typedParams, err = pingdom.TypedParams(alert.CheckType, alert.CheckParams)
+		if err != nil && !errors.Is(err, pingdom.ErrUnknownCheckType) {
+			msg = fmt.Sprintf(":warning: *Failed to decode the check parameters: %s* :warning: \n", escapeMarkdown(err.Error()))
+		}
+	}
+
+	switch params := typedParams.(type) {
+	case *pingdom.HTTPParams:
+		msg = fmt.Sprintf("%s**Hostname**: %s\n", msg, naString(params.Hostname))
+		msg = fmt.Sprintf("%s**Port**: %s\n", msg, naNumber(params.Port))
+		msg = fmt.Sprintf("%s**URL**: %s\n", msg, naCode(firstString(params.FullURL, params.URL)))
+		msg = fmt.Sprintf("%s**IPv6**: %s\n", msg, naBool(params.IPV6))
+		msg = fmt.Sprintf("%s**Encryption**: %s\n", msg, naBool(params.Encryption))
+		msg = fmt.Sprintf("%s**Verify Certificate**: %s\n", msg, naBool(params.VerifyCertificate))
+		msg = fmt.Sprintf("%s**Basic Auth**: %s\n", msg, naBool(params.BasicAuth))
+		msg = fmt.Sprintf("%s**Headers**: %s\n", msg, naHeaders(params.Header, params.RequestHeaders))
+		msg = fmt.Sprintf("%s**Should Contain**: %s\n", msg, naCode(params.ShouldContain))
+		msg = fmt.Sprintf("%s**Should Not Contain**: %s\n", msg, naCode(params.ShouldNotContain))
+		msg = fmt.Sprintf("%s**Post Data**: %s\n", msg, naCode(redactPostData(params.PostData)))
+	case *pingdom.HTTPCustomParams:
+		msg = fmt.Sprintf("%s**Hostname**: %s\n", msg, naString(params.Hostname))
+		msg = fmt.Sprintf("%s**Port**: %s\n", msg, naNumber(params.Port))
+		msg = fmt.Sprintf("%s**URL**: %s\n", msg, naCode(firstString(params.FullURL, params.URL)))
+		msg = fmt.Sprintf("%s**IPv6**: %s\n", msg, naBool(params.IPV6))
+		msg = fmt.Sprintf("%s**Encryption**: %s\n", msg, naBool(params.Encryption))
+		msg = fmt.Sprintf("%s**Basic Auth**: %s\n", msg, naBool(params.BasicAuth))
+		for _, additionalURL := range params.AdditionalURLs {
+			msg = fmt.Sprintf("%s**Additional URL**: `%s`\n", msg, escapeCode(additionalURL))
+		}
+	case *pingdom.DNSParams:
+		msg = fmt.Sprintf("%s**Hostname**: %s\n", msg, naString(params.Hostname))
+		msg = fmt.Sprintf("%s**Expected IP**: %s\n", msg, naCode(params.ExpectedIP))
+		msg = fmt.Sprintf("%s**Nameserver**: %s\n", msg, naCode(params.Nameserver))
+		msg = fmt.Sprintf("%s**IPv6**: %s\n", msg, naBool(params.IPV6))
+	case *pingdom.TCPParams:
+		msg = fmt.Sprintf("%s**Hostname**: %s\n", msg, naString(params.Hostname))
+		msg = fmt.Sprintf("%s**Port**: %s\n", msg, naNumber(params.Port))
+		msg = fmt.Sprintf("%s**IPv6**: %s\n", msg, naBool(params.IPV6))
+		msg = fmt.Sprintf("%s**String To Send**: %s\n", msg, naCode(params.StringToSend))
+		msg = fmt.Sprintf("%s**String To Expect**: %s\n", msg, naCode(params.StringToExpect))
+	case *pingdom.UDPParams:
+		msg = fmt.Sprintf("%s**Hostname**: %s\n", msg, naString(params.Hostname))
+		msg = fmt.Sprintf("%s**Port**: %s\n", msg, naNumber(params.Port))
+		msg = fmt.Sprintf("%s**IPv6**: %s\n", msg, naBool(params.IPV6))
+		msg = fmt.Sprintf("%s**String To Send**: %s\n", msg, naCode(params.StringToSend))
+		msg = fmt.Sprintf("%s**String To Expect**: %s\n", msg, naCode(params.StringToExpect))
+	case *pingdom.IMAPParams:
+		msg = renderMailParams(msg, params.MailParams)
+	case *pingdom.POP3Params:
+		msg = renderMailParams(msg, params.MailParams)
+	case *pingdom.SMTPParams:
+		msg = renderMailParams(msg, params.MailParams)
+	case *pingdom.PingParams:
+		msg = fmt.Sprintf("%s**Hostname**: %s\n", msg, naString(params.Hostname))
+		msg = fmt.Sprintf("%s**IPv6**: %s\n", msg, naBool(params.IPV6))
+	case *pingdom.TransactionParams:
+		msg = fmt.Sprintf("%s**Port**: %s\n", msg, naNumber(params.Port))
+		msg = fmt.Sprintf("%s**URL**: %s\n", msg, naCode(firstString(params.FullURL, params.URL)))
+		msg = fmt.Sprintf("%s**Encryption**: %s\n", msg, naBool(params.Encryption))
 	default:
-		msg = fmt.Sprintf("%s:warning: *Unknown check type, no additional fields had been collected.* :warning: \n", msg)
+		// The check type is unknown to us (or its parameters are malformed), so render whatever
+		// Pingdom had sent (hostname included).
+		rendered := renderCheckParams(alert.CheckParams, config.GetParamsFilter())
+		if rendered == "" {
+			rendered = ":warning: *Unknown check type, no check parameters had been received.* :warning: \n"
+		}
+		msg = fmt.Sprintf("%s%s", msg, rendered)
 	}
 	fields = addFields(fields, "Details", msg, true)
 
@@ -141,30 +389,45 @@ func ConvertPingdomToFields(config pingdomHookConfig, alert pingdom.PingdomCheck
 	if len(alert.Tags) > 0 {
 		wrapped := make([]string, len(alert.Tags))
 		for i, tag := range alert.Tags {
-			wrapped[i] = fmt.Sprintf("`%s`", tag)
+			wrapped[i] = fmt.Sprintf("`%s`", escapeCode(tag))
 		}
 		msg = fmt.Sprintf("%s%s\n", msg, strings.Join(wrapped, ", "))
 		fields = addFields(fields, "Tags", msg, false)
 	}
 
+	// Links to the provider and to the monitored resource
+	msg = ""
+	if alert.GetProvider() == uptime.ProviderPingdom {
+		msg = fmt.Sprintf("%s\n", markdownLink("Uptime report", pingdom.UptimeReportURL(config.PingdomBaseURL, alert.CheckID)))
+		msg = fmt.Sprintf("%s%s\n", msg, markdownLink("Root cause analysis", pingdom.RootCauseURL(config.PingdomBaseURL, alert.CheckID)))
+	} else if isURL(alert.ReportURL) {
+		msg = fmt.Sprintf("%s\n", markdownLink(fmt.Sprintf("%s report", uptime.ProviderName(alert.Provider)), alert.ReportURL))
+	}
+	if isURL(alert.MonitoredURL) {
+		msg = fmt.Sprintf("%s%s\n", msg, markdownLink("Monitored URL", alert.MonitoredURL))
+	}
+	if msg != "" {
+		fields = addFields(fields, "Links", msg, false)
+	}
+
+	if alert.FirstProbe == nil && alert.SecondProbe == nil {
+		// Push it out =]
+		return fields
+	}
+
 	fields = addFields(fields, "Probe Details", "", false)
 	// List probes
-	for _, probe := range []pingdom.CommonProbe{alert.FirstProbe, alert.SecondProbe} {
+	for i, probe := range []*uptime.Probe{alert.FirstProbe, alert.SecondProbe} {
 		if probe == nil {
 			continue
 		}
-		msg = fmt.Sprintf(":earth_americas: %s\n", probe.GetLocation())
-		msg = fmt.Sprintf("%s:house: %s\n", msg, probe.GetIP())
-		msg = fmt.Sprintf("%s:european_castle: %s\n", msg, probe.GetIPV6())
-
-		probeType := "Unknown"
-		switch probe.(type) {
-		case *pingdom.FirstProbe:
-			probeType = "First Probe"
-		case *pingdom.SecondProbe:
+		msg = fmt.Sprintf(":earth_americas: %s\n", naString(&probe.Location))
+		msg = fmt.Sprintf("%s:house: %s\n", msg, naString(&probe.IP))
+		msg = fmt.Sprintf("%s:european_castle: %s\n", msg, naString(&probe.IPV6))
+
+		probeType := "First Probe"
+		if i == 1 {
 			probeType = "Second Probe"
-		default:
-			probeType = "First Probe"
 		}
 		fields = addFields(fields, probeType, msg, true)
 	}
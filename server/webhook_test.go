@@ -0,0 +1,292 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zentavr/mattermost-plugin-pingdom/server/pingdom"
+	"github.com/zentavr/mattermost-plugin-pingdom/server/uptime"
+)
+
+func TestDecodeAlert(t *testing.T) {
+	const form = "application/x-www-form-urlencoded"
+	receivedAt := time.Unix(1700000000, 0)
+
+	for name, tc := range map[string]struct {
+		provider      string
+		body          string
+		contentType   string
+		expectedID    uint64
+		expectedState string
+		expectedErr   bool
+	}{
+		"pingdom": {
+			provider:      uptime.ProviderPingdom,
+			body:          `{"check_id": 1, "check_name": "example.com", "current_state": "DOWN"}`,
+			expectedID:    1,
+			expectedState: "DOWN",
+		},
+		"pingdom unknown fields": {
+			provider:      uptime.ProviderPingdom,
+			body:          `{"check_id": 1, "check_name": "example.com", "current_state": "UP", "new_field": {"nested": true}}`,
+			expectedID:    1,
+			expectedState: "UP",
+		},
+		"pingdom trailing newline": {
+			provider:   uptime.ProviderPingdom,
+			body:       "{\"check_id\": 1}\n",
+			expectedID: 1,
+		},
+		"pingdom trailing object": {
+			provider:    uptime.ProviderPingdom,
+			body:        `{"check_id": 1}{"check_id": 2}`,
+			expectedErr: true,
+		},
+		"pingdom trailing garbage": {
+			provider:    uptime.ProviderPingdom,
+			body:        `{"check_id": 1} garbage`,
+			expectedErr: true,
+		},
+		"pingdom array": {
+			provider:    uptime.ProviderPingdom,
+			body:        `[{"check_id": 1}]`,
+			expectedErr: true,
+		},
+		"pingdom wrong type": {
+			provider:    uptime.ProviderPingdom,
+			body:        `{"check_id": "1"}`,
+			expectedErr: true,
+		},
+		"pingdom legacy": {
+			provider:      uptime.ProviderPingdom,
+			body:          `{"check": "803318", "checkname": "example.com", "action": "assign", "description": "down"}`,
+			expectedID:    803318,
+			expectedState: "DOWN",
+		},
+		"pingdom legacy form": {
+			provider:      uptime.ProviderPingdom,
+			body:          `message=%7B%22check%22%3A%22803318%22%2C%22checkname%22%3A%22example.com%22%2C%22action%22%3A%22notify_of_close%22%7D`,
+			contentType:   form,
+			expectedID:    803318,
+			expectedState: "UP",
+		},
+		"uptimerobot JSON": {
+			provider:      uptime.ProviderUptimeRobot,
+			body:          `{"monitorID": "777", "monitorFriendlyName": "example.com", "alertType": "1", "alertDateTime": "1700000000"}`,
+			expectedID:    777,
+			expectedState: "DOWN",
+		},
+		"uptimerobot form": {
+			provider:      uptime.ProviderUptimeRobot,
+			body:          "monitorID=777&monitorFriendlyName=example.com&alertType=2",
+			contentType:   form,
+			expectedID:    777,
+			expectedState: "UP",
+		},
+		"uptimerobot numeric JSON": {
+			provider:      uptime.ProviderUptimeRobot,
+			body:          `{"monitorID": 777, "monitorFriendlyName": "example.com", "alertType": 1}`,
+			expectedID:    777,
+			expectedState: "DOWN",
+		},
+		"uptimerobot without monitor": {
+			provider:    uptime.ProviderUptimeRobot,
+			body:        `{"monitorFriendlyName": "example.com"}`,
+			expectedErr: true,
+		},
+		"statuscake": {
+			provider:      uptime.ProviderStatusCake,
+			body:          "TestID=42&Name=example.com&URL=https%3A%2F%2Fexample.com&Status=Down&StatusCode=503",
+			contentType:   form,
+			expectedID:    42,
+			expectedState: "DOWN",
+		},
+		"statuscake without name": {
+			provider:    uptime.ProviderStatusCake,
+			body:        "Status=Up",
+			contentType: form,
+			expectedErr: true,
+		},
+		"unknown provider": {
+			provider:    "unknown",
+			body:        `{}`,
+			expectedErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			a, err := decodeAlert(tc.provider, []byte(tc.body), tc.contentType, receivedAt)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if a.CheckID != tc.expectedID {
+				t.Errorf("expected check ID %d, got %d", tc.expectedID, a.CheckID)
+			}
+			if a.CurrentState != tc.expectedState {
+				t.Errorf("expected state %q, got %q", tc.expectedState, a.CurrentState)
+			}
+			if a.GetProvider() != tc.provider {
+				t.Errorf("expected provider %q, got %q", tc.provider, a.GetProvider())
+			}
+		})
+	}
+}
+
+func TestConvertAlertToFieldsCheckParams(t *testing.T) {
+	for name, tc := range map[string]struct {
+		checkType string
+		params    map[string]interface{}
+		expected  string
+	}{
+		"HTTP redacts the credentials": {
+			checkType: pingdom.CheckTypeHTTP,
+			params: map[string]interface{}{
+				"hostname":       "example.com",
+				"port":           "8443",
+				"full_url":       "https://example.com:8443/login",
+				"header":         "Authorization:Bearer abc",
+				"requestheaders": map[string]interface{}{"Cookie": "session=abc", "X-Api-Key": "abc", "Accept": "text/html"},
+				"postdata":       "user=admin&password=abc",
+			},
+			expected: "**Hostname**: example.com\n" +
+				"**Port**: 8443\n" +
+				"**URL**: `https://example.com:8443/login`\n" +
+				"**IPv6**: n/a\n" +
+				"**Encryption**: n/a\n" +
+				"**Verify Certificate**: n/a\n" +
+				"**Basic Auth**: n/a\n" +
+				"**Headers**: `Authorization: [redacted]`, `Accept: text/html`, `Cookie: [redacted]`, `X-Api-Key: [redacted]`\n" +
+				"**Should Contain**: n/a\n" +
+				"**Should Not Contain**: n/a\n" +
+				"**Post Data**: `user=admin&password=[redacted]`\n",
+		},
+		"HTTP redacts the JSON post data": {
+			checkType: pingdom.CheckTypeHTTP,
+			params:    map[string]interface{}{"hostname": "example.com", "postdata": `{"user":"admin","auth":{"token":"abc"}}`},
+			expected: "**Hostname**: example.com\n" +
+				"**Port**: n/a\n" +
+				"**URL**: n/a\n" +
+				"**IPv6**: n/a\n" +
+				"**Encryption**: n/a\n" +
+				"**Verify Certificate**: n/a\n" +
+				"**Basic Auth**: n/a\n" +
+				"**Headers**: n/a\n" +
+				"**Should Contain**: n/a\n" +
+				"**Should Not Contain**: n/a\n" +
+				"**Post Data**: `{\"auth\":\"[redacted]\",\"user\":\"admin\"}`\n",
+		},
+		"DNS": {
+			checkType: pingdom.CheckTypeDNS,
+			params:    map[string]interface{}{"hostname": "example.com", "expected_ip": "192.0.2.1", "nameserver": "ns1.example.com", "ipv6": false},
+			expected:  "**Hostname**: example.com\n**Expected IP**: `192.0.2.1`\n**Nameserver**: `ns1.example.com`\n**IPv6**: no\n",
+		},
+		"TCP": {
+			checkType: pingdom.CheckTypeTCP,
+			params:    map[string]interface{}{"hostname": "example.com", "port": float64(5432), "stringtosend": "PING", "stringtoexpect": "PONG"},
+			expected:  "**Hostname**: example.com\n**Port**: 5432\n**IPv6**: n/a\n**String To Send**: `PING`\n**String To Expect**: `PONG`\n",
+		},
+		"UDP": {
+			checkType: pingdom.CheckTypeUDP,
+			params:    map[string]interface{}{"hostname": "example.com", "port": "53", "ipv6": true},
+			expected:  "**Hostname**: example.com\n**Port**: 53\n**IPv6**: yes\n**String To Send**: n/a\n**String To Expect**: n/a\n",
+		},
+		"SMTP": {
+			checkType: pingdom.CheckTypeSMTP,
+			params:    map[string]interface{}{"hostname": "mail.example.com", "port": float64(25), "encryption": false, "stringtoexpect": "220"},
+			expected:  "**Hostname**: mail.example.com\n**Port**: 25\n**IPv6**: n/a\n**Encryption**: no\n**String To Expect**: `220`\n",
+		},
+		"POP3": {
+			checkType: pingdom.CheckTypePOP3,
+			params:    map[string]interface{}{"hostname": "mail.example.com", "port": float64(995), "encryption": true},
+			expected:  "**Hostname**: mail.example.com\n**Port**: 995\n**IPv6**: n/a\n**Encryption**: yes\n**String To Expect**: n/a\n",
+		},
+		"IMAP": {
+			checkType: pingdom.CheckTypeIMAP,
+			params:    map[string]interface{}{"hostname": "mail.example.com", "port": "993"},
+			expected:  "**Hostname**: mail.example.com\n**Port**: 993\n**IPv6**: n/a\n**Encryption**: n/a\n**String To Expect**: n/a\n",
+		},
+		"PING": {
+			checkType: pingdom.CheckTypePing,
+			params:    map[string]interface{}{"hostname": "example.com", "ipv6": false},
+			expected:  "**Hostname**: example.com\n**IPv6**: no\n",
+		},
+		"TRANSACTION": {
+			checkType: pingdom.CheckTypeTransaction,
+			params:    map[string]interface{}{"full_url": "https://example.com/login", "port": float64(443), "encryption": true},
+			expected:  "**Port**: 443\n**URL**: `https://example.com/login`\n**Encryption**: yes\n",
+		},
+		"malformed port": {
+			checkType: pingdom.CheckTypeTCP,
+			params:    map[string]interface{}{"hostname": "example.com", "port": "https"},
+			expected:  ":warning: *Failed to decode the check parameters: failed to decode PORT\\_TCP check\\_params: invalid number \"https\"* :warning: \n**Hostname**: example.com\n**Port**: https\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			alert := uptime.Alert{Provider: uptime.ProviderPingdom, CheckID: 1, CheckType: tc.checkType, CheckParams: tc.params}
+			for _, field := range ConvertAlertToFields(pingdomHookConfig{}, alert) {
+				if field.Title == "Details" {
+					if field.Value != tc.expected {
+						t.Errorf("expected:\n%s\ngot:\n%s", tc.expected, field.Value)
+					}
+					return
+				}
+			}
+			t.Error("expected the details field")
+		})
+	}
+}
+
+func TestDeliverAlertTimeProps(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Kyiv"); err != nil {
+		t.Skipf("the time zone database is not available: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		timezone          string
+		expectedChangedAt string
+		expectedTimezone  string
+	}{
+		"hook timezone": {
+			timezone:          "Europe/Kyiv",
+			expectedChangedAt: "2024-07-01 15:30:00",
+			expectedTimezone:  "Europe/Kyiv",
+		},
+		"invalid timezone": {
+			timezone:          "Mars/Olympus",
+			expectedChangedAt: "2024-07-01 12:30:00",
+			expectedTimezone:  "UTC",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			api := newFakeAPI()
+			config := pingdomHookConfig{ID: "0", Team: "team", Channel: "alerts", Seed: "seed", Timezone: tc.timezone, TimeFormat: "DateTime"}
+			if err := config.IsValid(); err != nil {
+				t.Fatalf("expected the hook to stay valid, got %v", err)
+			}
+			p := newTestPlugin(api, &configuration{PingdomHooksConfigs: map[string]pingdomHookConfig{"0": config}})
+			p.channels.set("0", "channel-0", time.Now())
+
+			message := uptime.Alert{CheckID: 1, CheckName: "web", CurrentState: "DOWN", PreviousState: "UP", StateChangedTimestamp: 1719837000, Test: true}
+			postID, err := p.deliverAlert(config, message)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			post := api.posts[postID]
+			for prop, expected := range map[string]string{
+				propChangedAt:  tc.expectedChangedAt,
+				propTimezone:   tc.expectedTimezone,
+				propTimeFormat: time.DateTime,
+			} {
+				if actual := post.GetProp(prop); actual != expected {
+					t.Errorf("expected %s to be %q, got %v", prop, expected, actual)
+				}
+			}
+		})
+	}
+}
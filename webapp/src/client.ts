@@ -0,0 +1,112 @@
+// Copyright (c) 2025-present Andrii Miroshnychenko. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import manifest from '@/manifest';
+
+// CheckState mirrors the checkState of the server: the latest known state of the Pingdom check.
+export type CheckState = {
+    hook_id: string;
+    check_id: number;
+    check_name: string;
+    state: string;
+    previous_state: string;
+    changed_at: number;
+    received_at: number;
+    post_id?: string;
+};
+
+// SeedUsage mirrors the seedUsageResponse of the server, the timestamps are in milliseconds.
+export type SeedUsage = {
+    seed_hint: string;
+    current: boolean;
+    expires_at?: number;
+    last_used_at?: number;
+};
+
+// HookStats mirrors the hookStats of the server, the timestamps are in milliseconds.
+export type HookStats = {
+    hook_id: string;
+    received: number;
+    rejected: number;
+    delivered: number;
+    failures: number;
+    last_received_at?: number;
+    last_check_name?: string;
+    last_error?: string;
+    last_error_at?: number;
+};
+
+// TestAlertResult mirrors the testAlertResponse of the server.
+export type TestAlertResult = {
+    status: number;
+    message: string;
+};
+
+const pluginUrl = () => `${window.basename || ''}/plugins/${manifest.id}`;
+
+// The Mattermost server requires the CSRF token for the non-GET requests authenticated by the cookie.
+const csrfToken = () => {
+    const match = document.cookie.match(/(?:^|;\s*)MMCSRF=([^;]+)/);
+    return match ? match[1] : '';
+};
+
+const doRequest = (url: string, init: RequestInit = {}) => {
+    return fetch(url, {
+        ...init,
+        credentials: 'include',
+        headers: {
+            'X-Requested-With': 'XMLHttpRequest',
+            'X-CSRF-Token': csrfToken(),
+            ...init.headers,
+        },
+    });
+};
+
+const doFetch = async <T>(url: string, init: RequestInit = {}): Promise<T> => {
+    const response = await doRequest(url, init);
+    if (!response.ok) {
+        throw new Error(`${url} responded with ${response.status}`);
+    }
+    return response.json() as Promise<T>;
+};
+
+export const getCheckState = (hookId: string, checkId: number) => {
+    return doFetch<CheckState>(`${pluginUrl()}/api/v1/checks/state?hook_id=${encodeURIComponent(hookId)}&check_id=${checkId}`);
+};
+
+export const acknowledgeAlert = (postId: string) => {
+    return doFetch<Record<string, unknown>>(`${pluginUrl()}/api/v1/alerts/acknowledge`, {
+        method: 'POST',
+        headers: {'Content-Type': 'application/json'},
+        body: JSON.stringify({post_id: postId}),
+    });
+};
+
+export const getSeedUsage = (hookId: string) => {
+    return doFetch<SeedUsage[]>(`${pluginUrl()}/api/v1/hooks/seeds?hook_id=${encodeURIComponent(hookId)}`);
+};
+
+export const getHookStats = (hookId: string) => {
+    return doFetch<HookStats>(`${pluginUrl()}/api/v1/hooks/stats?hook_id=${encodeURIComponent(hookId)}`);
+};
+
+export const sendTestAlert = async (hookId: string, state: string, type: string): Promise<TestAlertResult> => {
+    const url = `${pluginUrl()}/api/v1/hooks/test`;
+    const response = await doRequest(url, {
+        method: 'POST',
+        headers: {'Content-Type': 'application/json'},
+        body: JSON.stringify({hook_id: hookId, state, type}),
+    });
+
+    // The failed test alert is answered with the status and the response of the webhook as well
+    if (response.headers.get('Content-Type')?.startsWith('application/json')) {
+        return response.json() as Promise<TestAlertResult>;
+    }
+    throw new Error(`${url} responded with ${response.status}`);
+};
+
+declare global {
+    interface Window {
+        basename?: string;
+    }
+}
@@ -3,16 +3,47 @@ import crypto from 'crypto';
 import React, {useState, useEffect} from 'react';
 import {useIntl} from 'react-intl';
 import {leftCol, rightCol, LabelRow, RadioInput, RadioInputLabel} from 'src/components/admin_settings/common';
+import {getHookStats, getSeedUsage, HookStats, SeedUsage, sendTestAlert} from '@/client';
+import manifest from '@/manifest';
 import '@/sass/pingdom/module.scss';
 
+export type RetiredSeed = {
+  seed: string;               // The previous seed which keeps working until it expires
+  expiresAt: number;          // Unix time in milliseconds
+};
+
 export type WebhookMattermostAttributes = {
   disabled: boolean;          // If our webhook should be disabled
   channel: string;            // Mattermost channel where send an alert to
   team: string;               // Mattermost team/org
+  target: string;             // Where the alerts are posted: the channel (if empty), 'direct' or 'group' message
+  provider: string;           // Uptime monitoring service the alerts come from (Pingdom if empty)
   seed: string;               // The secret seed phrase, which is used as a suffix for the webhook
   token: string;              // Pingdom token to use when talking to Pingdom API
+  timezone: string;           // IANA timezone the alert timestamps are rendered in
+  timeFormat: string;         // Go time layout or its well-known name (RFC1123, RFC3339, ...)
+  paramsAllowList: string;    // Comma-separated check params to show for unknown check types
+  paramsDenyList: string;     // Comma-separated check params to hide for unknown check types
+  pingdomBaseURL: string;     // Pingdom UI the alerts link to
+  authMethod: string;         // The way the calls are authenticated besides the query string seed
+  disableQuerySeed: boolean;  // If the seed in the query string should be rejected
+  authHeader: string;         // Header carrying the seed or the HMAC signature
+  basicUsername: string;      // Username of the HTTP Basic credentials
+  retiredSeeds: RetiredSeed[]; // The previous seeds which keep working until they expire
+  seedGracePeriodHours: number; // Hours the regenerated seed keeps working
+  allowedCIDRs: string;       // Comma-separated CIDRs the calls may come from
+  allowPingdomProbes: boolean; // If the calls from the Pingdom probes are allowed
+  trustedProxies: string;     // Comma-separated CIDRs of the trusted reverse proxies
+  rateLimitPerMinute: number; // Alerts per minute the hook accepts
+  rateLimitBurst: number;     // Alerts the hook accepts at once
+  historyRetentionDays: number; // Days the alerts are kept in the history
+  heartbeatIntervalHours: number; // Hours the hook may stay silent before the watchdog warns (off if 0)
+  privateChannel: boolean;    // If the missing channel is created as the private one
 };
 
+// The same as defaultSeedGracePeriodHours of the server
+const defaultSeedGracePeriodHours = 24;
+
 const initErrors = {
     teamError: false,
     channelError: false,
@@ -28,6 +59,10 @@ interface WebHookAttributeProps {
     onDelete: (id: string) => void;
 }
 
+function isChannelTarget(target: string) {
+    return !target || target === 'channel';
+}
+
 function validateWebhookConfiguration(attributes: WebhookMattermostAttributes) {
     // Start with all errors = false
     const errors = { ...initErrors };
@@ -35,7 +70,8 @@ function validateWebhookConfiguration(attributes: WebhookMattermostAttributes) {
     if (!attributes.channel || attributes.channel.trim() === '') {
         errors.channelError = true;
     }
-    if (!attributes.team || attributes.team.trim() === '') {
+    // The direct and the group messages do not belong to a team
+    if (isChannelTarget(attributes.target) && (!attributes.team || attributes.team.trim() === '')) {
         errors.teamError = true;
     }
     if (!attributes.seed || attributes.seed.trim() === '') {
@@ -52,20 +88,66 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
           disabled: false,
           channel: '',
           team: '',
+          target: '',
+          provider: '',
           seed: '',
-          token: ''
+          token: '',
+          timezone: '',
+          timeFormat: '',
+          paramsAllowList: '',
+          paramsDenyList: '',
+          pingdomBaseURL: '',
+          authMethod: '',
+          disableQuerySeed: false,
+          authHeader: '',
+          basicUsername: '',
+          seedGracePeriodHours: 0,
+          retiredSeeds: [],
+          allowedCIDRs: '',
+          allowPingdomProbes: false,
+          trustedProxies: '',
+          rateLimitPerMinute: 0,
+          rateLimitBurst: 0,
+          historyRetentionDays: 0,
+          heartbeatIntervalHours: 0,
+          privateChannel: false
         } :
         {
           disabled: props.attributes.disabled ?? false,
           channel: props.attributes.channel ?? '',
           team: props.attributes.team ?? '',
+          target: props.attributes.target ?? '',
+          provider: props.attributes.provider ?? '',
           seed: props.attributes.seed ?? '',
-          token: props.attributes.token ?? ''
+          token: props.attributes.token ?? '',
+          timezone: props.attributes.timezone ?? '',
+          timeFormat: props.attributes.timeFormat ?? '',
+          paramsAllowList: props.attributes.paramsAllowList ?? '',
+          paramsDenyList: props.attributes.paramsDenyList ?? '',
+          pingdomBaseURL: props.attributes.pingdomBaseURL ?? '',
+          authMethod: props.attributes.authMethod ?? '',
+          disableQuerySeed: props.attributes.disableQuerySeed ?? false,
+          authHeader: props.attributes.authHeader ?? '',
+          basicUsername: props.attributes.basicUsername ?? '',
+          seedGracePeriodHours: props.attributes.seedGracePeriodHours ?? 0,
+          retiredSeeds: props.attributes.retiredSeeds ?? [],
+          allowedCIDRs: props.attributes.allowedCIDRs ?? '',
+          allowPingdomProbes: props.attributes.allowPingdomProbes ?? false,
+          trustedProxies: props.attributes.trustedProxies ?? '',
+          rateLimitPerMinute: props.attributes.rateLimitPerMinute ?? 0,
+          rateLimitBurst: props.attributes.rateLimitBurst ?? 0,
+          historyRetentionDays: props.attributes.historyRetentionDays ?? 0,
+          heartbeatIntervalHours: props.attributes.heartbeatIntervalHours ?? 0,
+          privateChannel: props.attributes.privateChannel ?? false
     };
 
     const [ settings, setSettings ] = useState(initialSettings);
     const [ hasError, setHasError ] = useState(initErrors);
-    const {formatMessage} = useIntl();
+    const [ seedUsage, setSeedUsage ] = useState<SeedUsage[]>([]);
+    const [ hookStats, setHookStats ] = useState<HookStats | null>(null);
+    const [ testAlertState, setTestAlertState ] = useState('DOWN');
+    const [ testAlertResult, setTestAlertResult ] = useState('');
+    const {formatMessage, formatRelativeTime} = useIntl();
 
     // Check the `attributes` whenever they change
     useEffect(() => {
@@ -73,13 +155,36 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
         setHasError(newErrors);
     }, [props.attributes]);
 
+    // Tell the admin when the seeds were used last time
+    useEffect(() => {
+        getSeedUsage(props.id).
+            then(setSeedUsage).
+            catch((err) => console.debug('PingdomWebHook/getSeedUsage failed: ' + err));
+    }, [props.id]);
+
+    // Tell the admin whether Pingdom actually calls the hook
+    useEffect(() => {
+        getHookStats(props.id).
+            then(setHookStats).
+            catch((err) => console.debug('PingdomWebHook/getHookStats failed: ' + err));
+    }, [props.id]);
+
     const regenerateSeed = (event: React.MouseEvent<HTMLButtonElement>) => {
         console.debug('regenerateSeed got called');
         event.preventDefault();
 
         const seed = crypto.randomBytes(256).toString('base64').replaceAll('+', '').replaceAll('/', '').substring(0, 32);
+
+        // The previous seed keeps working for the grace period, so there is time to update Pingdom
+        const now = Date.now();
+        const gracePeriodHours = settings.seedGracePeriodHours > 0 ? settings.seedGracePeriodHours : defaultSeedGracePeriodHours;
+        const retiredSeeds = settings.retiredSeeds.filter((retired) => retired.expiresAt > now);
+        if (settings.seed) {
+            retiredSeeds.push({seed: settings.seed, expiresAt: now + (gracePeriodHours * 60 * 60 * 1000)});
+        }
+
         let newSettings = {...settings};
-        newSettings = {...newSettings, seed: seed};
+        newSettings = {...newSettings, seed: seed, retiredSeeds: retiredSeeds};
         console.debug('regenerateSeed/New seed: ' + seed);
         console.debug('regenerateSeed/New settings: ' + JSON.stringify(newSettings));
         console.debug('regenerateSeed/props.id: ' + JSON.stringify(props.id));
@@ -88,6 +193,57 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
         props.onChange(props.id, newSettings);
     };
 
+    const revokeSeed = (seed: string) => {
+        console.debug('revokeSeed got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, retiredSeeds: settings.retiredSeeds.filter((retired) => retired.seed !== seed)};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    };
+
+    // The test alert goes through the saved configuration of the hook, the same way `/pingdom test` does
+    const handleSendTestAlert = (event: React.MouseEvent<HTMLButtonElement>) => {
+        event.preventDefault();
+        setTestAlertResult('');
+        sendTestAlert(props.id, testAlertState, 'HTTP').
+            then((result) => setTestAlertResult(formatMessage({defaultMessage: 'The webhook responded with {status}: {message}'}, {status: result.status, message: result.message}))).
+            catch((err) => setTestAlertResult(formatMessage({defaultMessage: 'Failed to send the test alert: {error}'}, {error: String(err)})));
+    };
+
+    // The hook is addressed by its ID, so the proxy rules and the logs can be attributed to it
+    const webhookUrl = (seed: string) => `${window.location.origin}${window.basename || ''}/plugins/${manifest.id}/api/webhook/${encodeURIComponent(props.id)}?seed=${encodeURIComponent(seed)}`;
+
+    const seedHint = (seed: string) => (seed.length <= 8 ? '****' : `${seed.substring(0, 4)}...${seed.substring(seed.length - 4)}`);
+
+    const lastUsed = (seed: string) => {
+        const usage = seedUsage.find((item) => item.seed_hint === seedHint(seed));
+        if (!usage || !usage.last_used_at) {
+            return formatMessage({defaultMessage: 'never'});
+        }
+        return new Date(usage.last_used_at).toLocaleString();
+    };
+
+    const timeAgo = (timestamp: number) => {
+        const seconds = Math.round((timestamp - Date.now()) / 1000);
+        const units: Array<[Intl.RelativeTimeFormatUnit, number]> = [['day', 86400], ['hour', 3600], ['minute', 60]];
+        for (const [unit, size] of units) {
+            if (Math.abs(seconds) >= size) {
+                return formatRelativeTime(Math.round(seconds / size), unit);
+            }
+        }
+        return formatRelativeTime(seconds, 'second');
+    };
+
+    const hookStatsSummary = (stats: HookStats) => {
+        const lastAlert = stats.last_received_at ?
+            formatMessage({defaultMessage: 'Last alert: {time} ({check})'}, {time: timeAgo(stats.last_received_at), check: stats.last_check_name}) :
+            formatMessage({defaultMessage: 'Last alert: never'});
+        return formatMessage(
+            {defaultMessage: '{lastAlert}, {received} received, {failures} failures, {rejected} rejected'},
+            {lastAlert, received: stats.received, failures: stats.failures, rejected: stats.rejected},
+        );
+    };
+
     const handleDelete = (event: React.MouseEvent<HTMLDivElement>) => {
         props.onDelete(props.id);
     }
@@ -126,7 +282,7 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
 
     const handleWebhookTeamInput = (event: React.ChangeEvent<HTMLInputElement>) => {
         console.debug('handleWebhookTeamInput got called');
-        if (!event.target.value || event.target.value.trim() === '') {
+        if (isChannelTarget(settings.target) && (!event.target.value || event.target.value.trim() === '')) {
             setHasError({...hasError, teamError: true});
         } else {
             setHasError({...hasError, teamError: false});
@@ -137,6 +293,15 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
         props.onChange(props.id, newSettings);
     }
 
+    const handleWebhookTargetInput = (event: React.ChangeEvent<HTMLSelectElement>) => {
+        console.debug('handleWebhookTargetInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, target: event.target.value};
+        setSettings(newSettings);
+        setHasError(validateWebhookConfiguration(newSettings));
+        props.onChange(props.id, newSettings);
+    }
+
     const handleWebhookTokenInput = (event: React.ChangeEvent<HTMLInputElement>) => {
         console.debug('handleWebhookTokenInput got called');
         let newSettings = {...settings};
@@ -145,6 +310,158 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
         props.onChange(props.id, newSettings);
     }
 
+    const handleWebhookTimezoneInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookTimezoneInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, timezone: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookTimeFormatInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookTimeFormatInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, timeFormat: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookParamsAllowListInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookParamsAllowListInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, paramsAllowList: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookParamsDenyListInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookParamsDenyListInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, paramsDenyList: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookPingdomBaseURLInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookPingdomBaseURLInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, pingdomBaseURL: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookProviderInput = (event: React.ChangeEvent<HTMLSelectElement>) => {
+        console.debug('handleWebhookProviderInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, provider: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookAuthMethodInput = (event: React.ChangeEvent<HTMLSelectElement>) => {
+        console.debug('handleWebhookAuthMethodInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, authMethod: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookDisableQuerySeedInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookDisableQuerySeedInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, disableQuerySeed: event.target.value === 'true'};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookAuthHeaderInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookAuthHeaderInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, authHeader: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookBasicUsernameInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookBasicUsernameInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, basicUsername: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookSeedGracePeriodHoursInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookSeedGracePeriodHoursInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, seedGracePeriodHours: parseInt(event.target.value, 10) || 0};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookAllowedCIDRsInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookAllowedCIDRsInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, allowedCIDRs: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookAllowPingdomProbesInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookAllowPingdomProbesInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, allowPingdomProbes: event.target.value === 'true'};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookTrustedProxiesInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookTrustedProxiesInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, trustedProxies: event.target.value};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookRateLimitPerMinuteInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookRateLimitPerMinuteInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, rateLimitPerMinute: parseInt(event.target.value, 10) || 0};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookRateLimitBurstInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookRateLimitBurstInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, rateLimitBurst: parseInt(event.target.value, 10) || 0};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookHistoryRetentionDaysInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookHistoryRetentionDaysInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, historyRetentionDays: parseInt(event.target.value, 10) || 0};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookHeartbeatIntervalHoursInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookHeartbeatIntervalHoursInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, heartbeatIntervalHours: parseInt(event.target.value, 10) || 0};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
+    const handleWebhookPrivateChannelInput = (event: React.ChangeEvent<HTMLInputElement>) => {
+        console.debug('handleWebhookPrivateChannelInput got called');
+        let newSettings = {...settings};
+        newSettings = {...newSettings, privateChannel: event.target.value === 'true'};
+        setSettings(newSettings);
+        props.onChange(props.id, newSettings);
+    }
+
     console.debug('PingdomWebHook/typeOf field/disabled: ' + JSON.stringify(typeof props.attributes.disabled));
     console.debug('PingdomWebHook/value of field/disabled: ' + JSON.stringify(props.attributes.disabled));
     console.debug('PingdomWebHook/value of settings: ' + JSON.stringify(settings));
@@ -198,7 +515,34 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
                         </div>
                     </div>
                 </div>
+                {/*  Webhook's target */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Target'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <select
+                            data-testid={props.id + 'input'}
+                            id={'target' + '.' + props.id}
+                            className='form-control'
+                            value={settings.target}
+                            onChange={handleWebhookTargetInput}
+                        >
+                            <option value=''>{formatMessage({defaultMessage: 'Channel'})}</option>
+                            <option value='direct'>{formatMessage({defaultMessage: 'Direct Message'})}</option>
+                            <option value='group'>{formatMessage({defaultMessage: 'Group Message'})}</option>
+                        </select>
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Where the alerts are posted to: the channel of the team, the direct message of the bot with the user or the group message with the users.'})}
+                        </div>
+                    </div>
+                </div>
                 {/*  Webhook's team */}
+                {isChannelTarget(settings.target) && (
                 <div data-testid={props.id} className='form-group'>
                     <div className={classNames('control-label', leftCol)}>
                         <LabelRow>
@@ -221,6 +565,7 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
                         </div>
                     </div>
                 </div>
+                )}
                 {/*  Webhook's channel */}
                 <div data-testid={props.id} className='form-group'>
                     <div className={classNames('control-label', leftCol)}>
@@ -240,10 +585,49 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
                             onChange={handleWebhookChannelInput}
                         />
                         <div data-testid={props.id + 'help-text'} className='help-text'>
-                            {formatMessage({defaultMessage: 'Channel you want to send messages to. Use the channel name such as \'town-square\', instead of the display name.'})}
+                            {formatMessage({defaultMessage: 'Channel you want to send messages to. Use the channel name such as \'town-square\', instead of the display name. For the direct message set the username, for the group message the comma-separated usernames (2 to 7).'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Private Channel */}
+                {isChannelTarget(settings.target) && (
+                <div data-testid={props.id} className='form-group'>
+                    <label className={'control-label ' + leftCol}>
+                        {formatMessage({defaultMessage: 'Private Channel'})}
+                    </label>
+                    <div className={rightCol}>
+                        <RadioInputLabel $disabled={false}>
+                            <RadioInput
+                                data-testid={props.id + '_privateChannel_true'}
+                                type='radio'
+                                value='true'
+                                id={'privateChannel' + '.' + props.id + '_true'}
+                                name={'privateChannel' + '.' + props.id + '_true'}
+                                checked={settings.privateChannel}
+                                onChange={handleWebhookPrivateChannelInput}
+                                disabled={false}
+                            />
+                            {formatMessage({defaultMessage: 'On'})}
+                        </RadioInputLabel>
+                        <RadioInputLabel $disabled={false}>
+                            <RadioInput
+                                data-testid={props.id + '_privateChannel_false'}
+                                type='radio'
+                                value='false'
+                                id={'privateChannel' + '.' + props.id + '_false'}
+                                name={'privateChannel' + '.' + props.id + '_false'}
+                                checked={!settings.privateChannel}
+                                onChange={handleWebhookPrivateChannelInput}
+                                disabled={false}
+                            />
+                            {formatMessage({defaultMessage: 'Off'})}
+                        </RadioInputLabel>
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Create the missing channel as the private one. The bot is added to the existing private channel automatically.'})}
                         </div>
                     </div>
                 </div>
+                )}
                 {/* Seed */}
                 <div data-testid={props.id} className='form-group'>
                     <div className={classNames('control-label', leftCol)}>
@@ -267,6 +651,57 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
                                     onClick={regenerateSeed}
                             >{formatMessage({defaultMessage: 'Regenerate'})}</button>
                         </div>
+                        {settings.seed && (
+                            <div data-testid={props.id + 'help-text'} className='help-text'>
+                                {formatMessage({defaultMessage: 'Webhook URL: {url}'}, {url: webhookUrl(settings.seed)})}
+                            </div>
+                        )}
+                        {settings.seed && (
+                            <div data-testid={props.id + 'help-text'} className='help-text'>
+                                {formatMessage({defaultMessage: 'Last used: {time}'}, {time: lastUsed(settings.seed)})}
+                            </div>
+                        )}
+                        {hookStats && (
+                            <div data-testid={props.id + 'help-text'} className='help-text'>
+                                {hookStatsSummary(hookStats)}
+                            </div>
+                        )}
+                        {Boolean(hookStats?.last_error_at) && (
+                            <div data-testid={props.id + 'help-text'} className='help-text'>
+                                {formatMessage({defaultMessage: 'Last error: {time}, {error}'}, {time: timeAgo(hookStats?.last_error_at ?? 0), error: hookStats?.last_error})}
+                            </div>
+                        )}
+                        {settings.seed && (
+                            <div data-testid={props.id + 'help-text'} className='help-text'>
+                                <select
+                                    className='form-control'
+                                    value={testAlertState}
+                                    onChange={(e) => setTestAlertState(e.target.value)}
+                                >
+                                    <option value='DOWN'>{'DOWN'}</option>
+                                    <option value='UP'>{'UP'}</option>
+                                </select>
+                                <button type='button'
+                                        className={classNames('btn', 'btn-default')}
+                                        onClick={handleSendTestAlert}
+                                >{formatMessage({defaultMessage: 'Send Test Alert'})}</button>
+                                {testAlertResult && <span>{testAlertResult}</span>}
+                            </div>
+                        )}
+                        {settings.retiredSeeds.map((retired) => (
+                            <div
+                                key={retired.seed}
+                                className={classNames('pingdom-setting__retired-seed', {'pingdom-setting__retired-seed--expired': retired.expiresAt <= Date.now()})}
+                            >
+                                <code>{seedHint(retired.seed)}</code>
+                                <span>{formatMessage({defaultMessage: 'Expires: {time}'}, {time: new Date(retired.expiresAt).toLocaleString()})}</span>
+                                <span>{formatMessage({defaultMessage: 'Last used: {time}'}, {time: lastUsed(retired.seed)})}</span>
+                                <button type='button'
+                                        className={classNames('btn', 'btn-default')}
+                                        onClick={() => revokeSeed(retired.seed)}
+                                >{formatMessage({defaultMessage: 'Revoke'})}</button>
+                            </div>
+                        ))}
                     </div>
                 </div>
                 {/* Pingdom API Token  */}
@@ -292,6 +727,455 @@ const PingdomWebhookConfig = (props: WebHookAttributeProps) => {
                         </div>
                     </div>
                 </div>
+                {/* Provider */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Provider'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <select
+                            data-testid={props.id + 'input'}
+                            id={'provider' + '.' + props.id}
+                            className='form-control'
+                            value={settings.provider}
+                            onChange={handleWebhookProviderInput}
+                        >
+                            <option value=''>{formatMessage({defaultMessage: 'Pingdom'})}</option>
+                            <option value='uptimerobot'>{formatMessage({defaultMessage: 'UptimeRobot'})}</option>
+                            <option value='statuscake'>{formatMessage({defaultMessage: 'StatusCake'})}</option>
+                        </select>
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'The uptime monitoring service the webhook receives the alerts from. The alerts of every service are posted, threaded and kept in the history the same way.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Timezone */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Timezone'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'timezone' + '.' + props.id}
+                            className='form-control'
+                            type={'input'}
+                            value={settings.timezone}
+                            onChange={handleWebhookTimezoneInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Timezone the alert timestamps are shown in, such as \'Europe/Kyiv\'. UTC is used when empty.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Time Format */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Time Format'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'timeFormat' + '.' + props.id}
+                            className='form-control'
+                            type={'input'}
+                            value={settings.timeFormat}
+                            onChange={handleWebhookTimeFormatInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Go time layout such as \'2006-01-02 15:04:05 MST\' or one of RFC1123, RFC1123Z, RFC3339, RFC822, RFC850, ANSIC, UnixDate, Kitchen, DateTime. RFC1123 is used when empty.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Shown Check Parameters */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Shown Check Parameters'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'paramsAllowList' + '.' + props.id}
+                            className='form-control'
+                            type={'input'}
+                            value={settings.paramsAllowList}
+                            onChange={handleWebhookParamsAllowListInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Comma-separated check parameters (such as \'hostname,port\') to show for the check types the plugin does not know. All of them are shown when empty.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Hidden Check Parameters */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Hidden Check Parameters'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'paramsDenyList' + '.' + props.id}
+                            className='form-control'
+                            type={'input'}
+                            value={settings.paramsDenyList}
+                            onChange={handleWebhookParamsDenyListInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Comma-separated check parameters to hide for the check types the plugin does not know.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Pingdom Base URL */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Pingdom Base URL'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'pingdomBaseURL' + '.' + props.id}
+                            className='form-control'
+                            type={'input'}
+                            value={settings.pingdomBaseURL}
+                            onChange={handleWebhookPingdomBaseURLInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Pingdom UI the alerts link to, such as \'https://my.pingdom.com\'. Change it for the white-labelled or regional accounts. \'https://my.pingdom.com\' is used when empty.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Auth Method */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Auth Method'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <select
+                            data-testid={props.id + 'input'}
+                            id={'authMethod' + '.' + props.id}
+                            className='form-control'
+                            value={settings.authMethod}
+                            onChange={handleWebhookAuthMethodInput}
+                        >
+                            <option value=''>{formatMessage({defaultMessage: 'Query string seed only'})}</option>
+                            <option value='header'>{formatMessage({defaultMessage: 'Seed in a header'})}</option>
+                            <option value='basic'>{formatMessage({defaultMessage: 'HTTP Basic credentials'})}</option>
+                            <option value='hmac'>{formatMessage({defaultMessage: 'HMAC-SHA256 signature of the body'})}</option>
+                        </select>
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'The way the webhook calls are authenticated in addition to the seed in the query string. The seed is the secret for every method.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Disable Query String Seed */}
+                <div data-testid={props.id} className='form-group'>
+                    <label className={'control-label ' + leftCol}>
+                        {formatMessage({defaultMessage: 'Disable Query String Seed'})}
+                    </label>
+                    <div className={rightCol}>
+                        <RadioInputLabel $disabled={false}>
+                            <RadioInput
+                                data-testid={props.id + '_disableQuerySeed_true'}
+                                type='radio'
+                                value='true'
+                                id={'disableQuerySeed' + '.' + props.id + '_true'}
+                                name={'disableQuerySeed' + '.' + props.id + '_true'}
+                                checked={settings.disableQuerySeed}
+                                onChange={handleWebhookDisableQuerySeedInput}
+                                disabled={false}
+                            />
+                            {formatMessage({defaultMessage: 'On'})}
+                        </RadioInputLabel>
+                        <RadioInputLabel $disabled={false}>
+                            <RadioInput
+                                data-testid={props.id + '_disableQuerySeed_false'}
+                                type='radio'
+                                value='false'
+                                id={'disableQuerySeed' + '.' + props.id + '_false'}
+                                name={'disableQuerySeed' + '.' + props.id + '_false'}
+                                checked={!settings.disableQuerySeed}
+                                onChange={handleWebhookDisableQuerySeedInput}
+                                disabled={false}
+                            />
+                            {formatMessage({defaultMessage: 'Off'})}
+                        </RadioInputLabel>
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'When enabled, the seed in the query string (which leaks into the proxy access logs) is not accepted anymore.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Auth Header */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Auth Header'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'authHeader' + '.' + props.id}
+                            className='form-control'
+                            type={'input'}
+                            value={settings.authHeader}
+                            onChange={handleWebhookAuthHeaderInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Header which carries the seed (Header method) or the HMAC-SHA256 signature of the body (HMAC method). Defaults to \'X-Pingdom-Seed\' and \'X-Pingdom-Signature\' respectively.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Basic Username */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Basic Username'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'basicUsername' + '.' + props.id}
+                            className='form-control'
+                            type={'input'}
+                            value={settings.basicUsername}
+                            onChange={handleWebhookBasicUsernameInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Username of the HTTP Basic credentials (Basic method). The seed is the password.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Seed Grace Period (hours) */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Seed Grace Period (hours)'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'seedGracePeriodHours' + '.' + props.id}
+                            className='form-control'
+                            type={'number'}
+                            value={settings.seedGracePeriodHours}
+                            onChange={handleWebhookSeedGracePeriodHoursInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'How long the previous seed keeps working after the seed is regenerated, so there is time to update the Pingdom integration. 24 hours are used when empty.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Allowed CIDRs */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Allowed CIDRs'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'allowedCIDRs' + '.' + props.id}
+                            className='form-control'
+                            type={'input'}
+                            value={settings.allowedCIDRs}
+                            onChange={handleWebhookAllowedCIDRsInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Comma-separated list of CIDRs (or addresses) the webhook calls may come from, e.g. 192.0.2.0/24, 2001:db8::/32. Calls from anywhere are accepted if empty and the Pingdom probes are not allowed.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Allow Pingdom Probes */}
+                <div data-testid={props.id} className='form-group'>
+                    <label className={'control-label ' + leftCol}>
+                        {formatMessage({defaultMessage: 'Allow Pingdom Probes'})}
+                    </label>
+                    <div className={rightCol}>
+                        <RadioInputLabel $disabled={false}>
+                            <RadioInput
+                                data-testid={props.id + '_allowPingdomProbes_true'}
+                                type='radio'
+                                value='true'
+                                id={'allowPingdomProbes' + '.' + props.id + '_true'}
+                                name={'allowPingdomProbes' + '.' + props.id + '_true'}
+                                checked={settings.allowPingdomProbes}
+                                onChange={handleWebhookAllowPingdomProbesInput}
+                                disabled={false}
+                            />
+                            {formatMessage({defaultMessage: 'On'})}
+                        </RadioInputLabel>
+                        <RadioInputLabel $disabled={false}>
+                            <RadioInput
+                                data-testid={props.id + '_allowPingdomProbes_false'}
+                                type='radio'
+                                value='false'
+                                id={'allowPingdomProbes' + '.' + props.id + '_false'}
+                                name={'allowPingdomProbes' + '.' + props.id + '_false'}
+                                checked={!settings.allowPingdomProbes}
+                                onChange={handleWebhookAllowPingdomProbesInput}
+                                disabled={false}
+                            />
+                            {formatMessage({defaultMessage: 'Off'})}
+                        </RadioInputLabel>
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'When enabled, the calls from the Pingdom probe servers are accepted as well. Their addresses are fetched from the Pingdom API with the Token once a day.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Trusted Proxies */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Trusted Proxies'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'trustedProxies' + '.' + props.id}
+                            className='form-control'
+                            type={'input'}
+                            value={settings.trustedProxies}
+                            onChange={handleWebhookTrustedProxiesInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Comma-separated list of CIDRs of the reverse proxies in front of Mattermost. The client address is taken from X-Forwarded-For only when the call comes through one of them.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Rate Limit (alerts per minute) */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Rate Limit (alerts per minute)'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'rateLimitPerMinute' + '.' + props.id}
+                            className='form-control'
+                            type={'number'}
+                            value={settings.rateLimitPerMinute}
+                            onChange={handleWebhookRateLimitPerMinuteInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'The sustained number of the alerts per minute the webhook accepts, the alerts over the limit are dropped. 60 is used when empty.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Rate Limit Burst */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Rate Limit Burst'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'rateLimitBurst' + '.' + props.id}
+                            className='form-control'
+                            type={'number'}
+                            value={settings.rateLimitBurst}
+                            onChange={handleWebhookRateLimitBurstInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'The number of the alerts the webhook accepts at once before the rate limit applies. 20 is used when empty.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* History Retention (days) */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'History Retention (days)'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'historyRetentionDays' + '.' + props.id}
+                            className='form-control'
+                            type={'number'}
+                            value={settings.historyRetentionDays}
+                            onChange={handleWebhookHistoryRetentionDaysInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'How long the received alerts are kept for the /pingdom history command. 30 days are used when empty.'})}
+                        </div>
+                    </div>
+                </div>
+                {/* Heartbeat Interval (hours) */}
+                <div data-testid={props.id} className='form-group'>
+                    <div className={classNames('control-label', leftCol)}>
+                        <LabelRow>
+                            <label data-testid={props.id + 'label'} htmlFor={props.id}>
+                                {formatMessage({defaultMessage: 'Heartbeat Interval (hours)'})}
+                            </label>
+                        </LabelRow>
+                    </div>
+                    <div className={rightCol}>
+                        <input
+                            data-testid={props.id + 'input'}
+                            id={'heartbeatIntervalHours' + '.' + props.id}
+                            className='form-control'
+                            type={'number'}
+                            value={settings.heartbeatIntervalHours}
+                            onChange={handleWebhookHeartbeatIntervalHoursInput}
+                        />
+                        <div data-testid={props.id + 'help-text'} className='help-text'>
+                            {formatMessage({defaultMessage: 'Warn the channel and the system admins when the hook had not been called for this many hours. With the Pingdom API Token the silence is confirmed with the Pingdom API first. Off when 0.'})}
+                        </div>
+                    </div>
+                </div>
             </div>
         </div>
     );
@@ -24,10 +24,52 @@ const emptyWebhook: WebhookMattermostAttributes = {
     channel: '',
     // Mattermost team/org
     team: '',
+    // Where the alerts are posted: the channel (if empty), 'direct' or 'group' message
+    target: '',
+    // Uptime monitoring service the alerts come from (Pingdom if empty)
+    provider: '',
     // The secret seed phrase, which is used as a suffix for the webhook
     seed: '',
     // Pingdom token to use when talking to Pingdom API
-    token: ''
+    token: '',
+    // IANA timezone the alert timestamps are rendered in
+    timezone: '',
+    // Go time layout or its well-known name
+    timeFormat: '',
+    // Comma-separated check params to show for unknown check types
+    paramsAllowList: '',
+    // Comma-separated check params to hide for unknown check types
+    paramsDenyList: '',
+    // Pingdom UI the alerts link to
+    pingdomBaseURL: '',
+    // The way the calls are authenticated besides the query string seed
+    authMethod: '',
+    // If the seed in the query string should be rejected
+    disableQuerySeed: false,
+    // Header carrying the seed or the HMAC signature
+    authHeader: '',
+    // Username of the HTTP Basic credentials
+    basicUsername: '',
+    // Hours the regenerated seed keeps working
+    seedGracePeriodHours: 0,
+    // The previous seeds which keep working until they expire
+    retiredSeeds: [],
+    // Comma-separated CIDRs the calls may come from
+    allowedCIDRs: '',
+    // If the calls from the Pingdom probes are allowed
+    allowPingdomProbes: false,
+    // Comma-separated CIDRs of the trusted reverse proxies
+    trustedProxies: '',
+    // Alerts per minute the hook accepts
+    rateLimitPerMinute: 0,
+    // Alerts the hook accepts at once
+    rateLimitBurst: 0,
+    // Days the alerts are kept in the history
+    historyRetentionDays: 0,
+    // Hours the hook may stay silent before the watchdog warns (off if 0)
+    heartbeatIntervalHours: 0,
+    // If the missing channel is created as the private one
+    privateChannel: false
 };
 
 export default function WebhookConfig(props: WebhookConfigComponentProps) {
@@ -0,0 +1,220 @@
+// Copyright (c) 2025-present Andrii Miroshnychenko. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+import classNames from 'classnames';
+import React, {useEffect, useState} from 'react';
+import {useIntl} from 'react-intl';
+
+import {acknowledgeAlert, CheckState, getCheckState} from '@/client';
+import '@/sass/pingdom/module.scss';
+
+// PingdomAlert is the subset of the alert (uptime.Alert of the server) the card renders.
+type PingdomAlert = {
+    check_id: number;
+    check_name: string;
+    check_type: string;
+    current_state: string;
+    previous_state: string;
+    importance_level: string;
+    state_changed_timestamp: number;
+    reconciled?: boolean;
+};
+
+// AttachmentField is the field of the attachment (ConvertAlertToFields of the server), the card renders
+// the same fields as the other clients.
+type AttachmentField = {
+    title: string;
+    value: string;
+    short: boolean;
+};
+
+type Props = {
+    post: {
+        id: string;
+        props: Record<string, unknown>;
+    };
+};
+
+// checkStateRefreshInterval is how often the card asks whether the check is still in the state of
+// the alert.
+const checkStateRefreshInterval = 60 * 1000;
+
+// isWebLink tells if the link is safe to render: any user may create the post with these props
+// through the REST API, so e.g. the javascript: links are dropped.
+const isWebLink = (link: string) => {
+    try {
+        const url = new URL(link);
+        return url.protocol === 'http:' || url.protocol === 'https:';
+    } catch {
+        return false;
+    }
+};
+
+// relativeTimeInterval is how often the relative time of the state change is re-rendered.
+const relativeTimeInterval = 30 * 1000;
+
+// relativeTimeUnits are the units the relative time is rendered in, from the largest one.
+const relativeTimeUnits: Array<[Intl.RelativeTimeFormatUnit, number]> = [
+    ['year', 365 * 24 * 60 * 60],
+    ['month', 30 * 24 * 60 * 60],
+    ['day', 24 * 60 * 60],
+    ['hour', 60 * 60],
+    ['minute', 60],
+];
+
+// relativeTime picks the largest unit of the distance between the time and now, e.g. [-3, 'minute'].
+const relativeTime = (seconds: number): [number, Intl.RelativeTimeFormatUnit] => {
+    for (const [unit, size] of relativeTimeUnits) {
+        if (Math.abs(seconds) >= size) {
+            return [Math.trunc(seconds / size), unit];
+        }
+    }
+    return [0, 'second'];
+};
+
+// alertFields returns the fields of the attachment the server had rendered the alert into.
+const alertFields = (props: Record<string, unknown>): AttachmentField[] => {
+    const attachments = props.attachments as Array<{fields?: AttachmentField[]}> | undefined;
+    return attachments?.[0]?.fields ?? [];
+};
+
+// renderMarkdown renders the field with the Markdown renderer of the webapp, the text is shown as it
+// is when the renderer is not exposed.
+const renderMarkdown = (text: string) => {
+    const postUtils = window.PostUtils;
+    if (!postUtils) {
+        return text;
+    }
+    return postUtils.messageHtmlToComponent(postUtils.formatText(text, {atMentions: true}), false);
+};
+
+const isDown = (state: string) => state === 'DOWN' || state === 'FAILING';
+const isUp = (state: string) => state === 'UP' || state === 'SUCCESS';
+
+const stateClassName = (state: string) => classNames('pingdom-alert__state', {
+    'pingdom-alert__state--down': isDown(state),
+    'pingdom-alert__state--up': isUp(state),
+});
+
+export default function PingdomAlertPost(props: Props) {
+    const {formatMessage, formatRelativeTime} = useIntl();
+    const alert = props.post.props.pingdom_alert as PingdomAlert | undefined;
+    const hookId = props.post.props.pingdom_hook_id as string | undefined;
+    const rawTitleLink = props.post.props.pingdom_title_link;
+    const titleLink = typeof rawTitleLink === 'string' && isWebLink(rawTitleLink) ? rawTitleLink : undefined;
+
+    const [ latestState, setLatestState ] = useState<CheckState | null>(null);
+    const [ now, setNow ] = useState(Date.now());
+    const [ acknowledgedBy, setAcknowledgedBy ] = useState(props.post.props.pingdom_acknowledged_by as string | undefined);
+
+    useEffect(() => {
+        setAcknowledgedBy(props.post.props.pingdom_acknowledged_by as string | undefined);
+    }, [props.post.props.pingdom_acknowledged_by]);
+
+    useEffect(() => {
+        if (!alert || !hookId) {
+            return;
+        }
+        let cancelled = false;
+        const refresh = () => {
+            getCheckState(hookId, alert.check_id).
+                then((state) => {
+                    if (!cancelled) {
+                        setLatestState(state);
+                    }
+                }).
+                catch((err) => console.debug('PingdomAlertPost/getCheckState failed: ' + err));
+        };
+        refresh();
+        const timer = setInterval(refresh, checkStateRefreshInterval);
+        return () => {
+            cancelled = true;
+            clearInterval(timer);
+        };
+    }, [hookId, alert?.check_id]);
+
+    useEffect(() => {
+        const timer = setInterval(() => setNow(Date.now()), relativeTimeInterval);
+        return () => clearInterval(timer);
+    }, []);
+
+    if (!alert) {
+        return null;
+    }
+
+    const handleAcknowledge = (event: React.MouseEvent<HTMLButtonElement>) => {
+        event.preventDefault();
+        acknowledgeAlert(props.post.id).
+            then((postProps) => setAcknowledgedBy(postProps.pingdom_acknowledged_by as string)).
+            catch((err) => console.debug('PingdomAlertPost/acknowledgeAlert failed: ' + err));
+    };
+
+    // The time is formatted by the server in the Timezone and the TimeFormat of the hook.
+    const changedAtText = props.post.props.pingdom_changed_at as string | undefined;
+    const timezone = props.post.props.pingdom_timezone as string | undefined;
+    const [ relativeValue, relativeUnit ] = relativeTime(alert.state_changed_timestamp - (now / 1000));
+    const relativeText = formatRelativeTime(relativeValue, relativeUnit, {numeric: 'auto'});
+    const fields = alertFields(props.post.props);
+
+    // The legacy alerts have no check type
+    const title = alert.check_type ? `${alert.check_type}: ${alert.check_name}` : alert.check_name;
+    const isOutdated = latestState !== null && latestState.post_id !== props.post.id && latestState.state !== alert.current_state;
+
+    return (
+        <div className={classNames('pingdom-alert', {'pingdom-alert--down': isDown(alert.current_state), 'pingdom-alert--up': isUp(alert.current_state)})}>
+            <div className='pingdom-alert__title'>
+                {titleLink ? (
+                    <a
+                        href={titleLink}
+                        target='_blank'
+                        rel='noopener noreferrer'
+                    >{title}</a>
+                ) : title}
+            </div>
+            <div className='pingdom-alert__states'>
+                <span className={stateClassName(alert.previous_state)}>{alert.previous_state}</span>
+                {' → '}
+                <span className={stateClassName(alert.current_state)}>{alert.current_state}</span>
+                {' '}
+                <span
+                    className='pingdom-alert__time'
+                    title={timezone}
+                >{changedAtText ? `${changedAtText} (${relativeText})` : relativeText}</span>
+            </div>
+            {alert.reconciled && (
+                <div className='pingdom-alert__reconciled'>
+                    {formatMessage({defaultMessage: 'Missed webhook, reconciled from the Pingdom API'})}
+                </div>
+            )}
+            {isOutdated && latestState && (
+                <div className='pingdom-alert__latest'>
+                    {formatMessage({defaultMessage: 'Currently {state} again'}, {state: latestState.state})}
+                </div>
+            )}
+            {fields.length > 0 && (
+                <div className='pingdom-alert__fields'>
+                    {fields.map((field, i) => (
+                        <div
+                            key={`${i}-${field.title}`}
+                            className={classNames('pingdom-alert__field', {'pingdom-alert__field--short': field.short})}
+                        >
+                            {field.title && <div className='pingdom-alert__field-title'>{renderMarkdown(field.title)}</div>}
+                            {field.value && <div className='pingdom-alert__field-value'>{renderMarkdown(field.value)}</div>}
+                        </div>
+                    ))}
+                </div>
+            )}
+            <div className='pingdom-alert__footer'>
+                {acknowledgedBy ? (
+                    <span>{formatMessage({defaultMessage: 'Acknowledged'})}</span>
+                ) : (
+                    <button
+                        type='button'
+                        className={classNames('btn', 'btn-default')}
+                        onClick={handleAcknowledge}
+                    >{formatMessage({defaultMessage: 'Acknowledge'})}</button>
+                )}
+            </div>
+        </div>
+    );
+}
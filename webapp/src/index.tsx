@@ -1,6 +1,9 @@
 // Copyright (c) 2025-present Andrii Miroshnychenko. All Rights Reserved.
 // See LICENSE.txt for license information.
 
+import type React from 'react';
+
+import PingdomAlertPost from '@/components/alert_post';
 import GeneralSettingsSection from '@/components/admin_settings/sections/general_settings';
 import WebhookConfig from '@/components/admin_settings/webhook_config';
 
@@ -18,12 +21,20 @@ export default class Plugin {
         }
         // Webhook Configuration Form
         registry.registerAdminConsoleCustomSetting('PingdomHooksConfigs', WebhookConfig);
+        // Live-updating alert card
+        registry.registerPostTypeComponent('custom_pingdom_alert', PingdomAlertPost);
     }
 }
 
 declare global {
     interface Window {
         registerPlugin(pluginId: string, plugin: Plugin): void;
+
+        // The Markdown helpers the webapp exposes to the plugins.
+        PostUtils?: {
+            formatText(text: string, options?: Record<string, unknown>): string;
+            messageHtmlToComponent(html: string, isRHS?: boolean, options?: Record<string, unknown>): React.ReactNode;
+        };
     }
 }
 